@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/env"
+)
+
+// auditEntry is a line of the audit log recording a single mutation of an env file
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Host      string    `json:"host"`
+	File      string    `json:"file"`
+	Operation string    `json:"operation"`
+	Key       string    `json:"key,omitempty"`
+	Old       string    `json:"old,omitempty"`
+	New       string    `json:"new,omitempty"`
+	Forced    bool      `json:"forced,omitempty"`
+}
+
+// defaultAuditLog returns ~/.config/goenv/audit.log next to the user configuration
+func defaultAuditLog() string {
+	return filepath.Join(filepath.Dir(defaultConfigFile()), auditLogFile)
+}
+
+// hashValue returns the hex SHA-256 of value so the audit log never holds the value itself
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// operationOf names the mutation requested by the flags of state for the audit log
+func operationOf(state *stateful) string {
+	switch {
+	case state.generate && state.rotate:
+		return argRotate
+	case state.generate:
+		return argGenerate
+	case state.add:
+		return argAdd
+	case state.rm:
+		return argRm
+	case state.init:
+		return argInit
+	default:
+		return argWrite
+	}
+}
+
+// auditChange appends an auditEntry to -audit-log for every key that differs between before and after, or a single
+// entry without a key when nothing changed, warning on stderr when the log cannot be written, and records the change
+// in the journal of path unless it is an -undo or -redo
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		operation: the flag that caused the mutation such as add, rm, write, init or cleanall
+// 		path: the file that was mutated
+// 		before: the envs of path before the mutation
+// 		after: the envs of path after the mutation
+func auditChange(state *stateful, operation, path string, before, after map[string]string) {
+	if state.dryRun {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	host, _ := os.Hostname()
+	base := auditEntry{
+		Time:      time.Now().UTC(),
+		User:      env.User().Username,
+		Host:      host,
+		File:      abs,
+		Operation: operation,
+		Forced:    state.force && state.confirmed[path],
+	}
+	entries := make([]auditEntry, 0)
+	for _, key := range changedKeys(before, after) {
+		entry := base
+		entry.Key = key
+		if v, ok := before[key]; ok {
+			entry.Old = hashValue(v)
+		}
+		if v, ok := after[key]; ok {
+			entry.New = hashValue(v)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		entries = append(entries, base)
+	}
+	if err = appendAudit(state.auditLog, entries); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: could not write the audit log %s: %v\n", state.auditLog, err)
+	}
+	if operation != argUndo && operation != argRedo {
+		recordJournal(state, operation, path, before, after)
+	}
+}
+
+// appendAudit writes entries as JSON lines to the end of path, creating it with 0600 when needed
+func appendAudit(path string, entries []auditEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		line, marshalErr := json.Marshal(entry)
+		if marshalErr != nil {
+			_ = f.Close()
+			return marshalErr
+		}
+		if _, err = f.Write(append(line, '\n')); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// parseAuditTime reads -since and -until as RFC3339, a date such as 2006-01-02 or a duration ago such as 24h
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not RFC3339, %s or a duration", value, time.DateOnly)
+	}
+	return time.Now().Add(-d), nil
+}
+
+// Audit prints the entries of -audit-log, filtered by -file, -env, -since and -until when they are provided
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -audit -file .env.production -since 24h
+// 		goenv -audit -env DB_PASSWORD -json
+func Audit(figs figtree.Plant, state *stateful) {
+	var since, until time.Time
+	var err error
+	if len(state.since) > 0 {
+		if since, err = parseAuditTime(state.since); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s %v\n", argSince, err)
+			os.Exit(1)
+		}
+	}
+	if len(state.until) > 0 {
+		if until, err = parseAuditTime(state.until); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s %v\n", argUntil, err)
+			os.Exit(1)
+		}
+	}
+	file := ""
+	if flagGiven(argEnvFile) {
+		// -file defaults to .env, so only an explicit -file narrows the log down to one file
+		file, _ = filepath.Abs(state.Path)
+	}
+	f, err := os.Open(state.auditLog)
+	if os.IsNotExist(err) {
+		_, _ = fmt.Fprintf(os.Stderr, "%s does not exist yet\n", state.auditLog)
+		os.Exit(1)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "os.Open(%s) returned err: %v\n", state.auditLog, err)
+		os.Exit(1)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	found := make([]auditEntry, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry auditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if len(file) > 0 && entry.File != file {
+			continue
+		}
+		if len(state.env) > 0 && !strings.EqualFold(entry.Key, strings.TrimSpace(state.env)) {
+			continue
+		}
+		if !since.IsZero() && entry.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && entry.Time.After(until) {
+			continue
+		}
+		found = append(found, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", state.auditLog, err)
+		os.Exit(1)
+	}
+	if len(state.formats) > 0 {
+		output, marshalErr := json.MarshalIndent(found, "", "  ")
+		if marshalErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argAudit, marshalErr)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		os.Exit(0)
+	}
+	for _, entry := range found {
+		forced := ""
+		if entry.Forced {
+			forced = " (forced)"
+		}
+		fmt.Printf("%s %s@%s %s %s %s%s\n", entry.Time.Local().Format(time.DateTime), entry.User, entry.Host,
+			entry.Operation, entry.File, entry.Key, forced)
+	}
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("%d entries in %s\n", len(found), state.auditLog)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+)
+
+// captureEnvs filters environ down to keys starting with prefix that match includes and do not match excludes,
+// skipping shellNoise
+//
+// Parameters:
+// 		environ: the environment to capture
+// 		prefix: keys must start with prefix, empty allows every key
+// 		includes: globs a key must match, empty allows every key
+// 		excludes: globs that skip a key
+func captureEnvs(environ map[string]string, prefix string, includes, excludes []string) map[string]string {
+	captured := make(map[string]string)
+	for k, v := range withoutNoise(environ) {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if len(includes) > 0 && !matchAny(includes, k) {
+			continue
+		}
+		if matchAny(excludes, k) {
+			continue
+		}
+		captured[k] = v
+	}
+	return captured
+}
+
+// Capture reads the environment of this process, -pid or -environ into envs and hands them to Result with -quote
+// so they can be printed, exported or written to argEnvFile with -write
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -capture -prefix APP_ -file app.env -write
+// 		goenv -capture -pid 4242 -include 'DB_*,REDIS_*' -exclude '*_PASSWORD'
+func Capture(figs figtree.Plant, state *stateful) {
+	environ, source, err := processEnviron(state)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argCapture, err)
+		os.Exit(1)
+	}
+	envs := captureEnvs(environ, state.prefix, state.include, state.exclude)
+	if *figs.Bool(argVerbose) {
+		_, _ = fmt.Fprintf(os.Stderr, "captured %d of %d variables from %s\n", len(envs), len(environ), source)
+	}
+	state.quote = true
+	if !state.write && !state.mkAll && len(state.formats) == 0 && len(state.template) == 0 {
+		state.printer = true
+	}
+	Result(figs, envs, state)
+}
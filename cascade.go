@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cascadeFiles returns the dotenv-flow layers of dir for stage ordered from lowest to highest priority
+//
+// Parameters:
+// 		dir: the directory containing the env files
+// 		stage: the environment such as development or production, empty skips the staged layers
+func cascadeFiles(dir, stage string) []string {
+	layers := []string{filepath.Join(dir, envFileDefault)}
+	if len(stage) > 0 {
+		layers = append(layers, filepath.Join(dir, envFileDefault+"."+stage))
+	}
+	layers = append(layers, filepath.Join(dir, envFileLocal))
+	if len(stage) > 0 {
+		layers = append(layers, filepath.Join(dir, envFileDefault+"."+stage+".local"))
+	}
+	return layers
+}
+
+// existingFiles filters paths down to the files that exist
+func existingFiles(paths []string) []string {
+	found := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+// queryMerged answers -has and -is from the effective envs of the merged layers and includes instead of the first
+// line that matches, exiting like Run does when a key or value is found
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		envs: map of the effective environment variables as key=value pairs
+func queryMerged(state *stateful, envs map[string]string) {
+	for key, value := range envs {
+		isThis := strings.EqualFold(key, strings.TrimSpace(state.env))
+		if state.has && isThis {
+			queryFound(state)
+		}
+		if !state.is {
+			continue
+		}
+		if isThis {
+			plain, err := decryptValue(state, value)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error decrypting %s: %v\n", key, err)
+				os.Exit(1)
+			}
+			value = plain
+		}
+		if strings.EqualFold(value, strings.TrimSpace(state.value)) {
+			queryFound(state)
+		}
+	}
+}
+
+// queryFound exits once -has or -is found a match, with 1 when -not is set
+func queryFound(state *stateful) {
+	code := 0
+	if state.not {
+		code = 1
+	}
+	if state.printer {
+		if code == 1 {
+			fmt.Println("YES")
+		} else {
+			fmt.Println("NO")
+		}
+	}
+	os.Exit(code)
+}
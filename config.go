@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/andreimerlescu/checkfs"
 	"github.com/andreimerlescu/checkfs/file"
@@ -11,6 +13,20 @@ import (
 	"github.com/andreimerlescu/goenv/env"
 )
 
+// defaultConfigFile returns ~/.config/goenv/config.yml
+func defaultConfigFile() string {
+	return filepath.Join(env.User().HomeDir, ".config", "goenv", "config.yml")
+}
+
+// userConfigFile returns AmGoEnvConfigFile or defaultConfigFile when the file exists
+func userConfigFile() (string, bool) {
+	configFile := env.String(AmGoEnvConfigFile, defaultConfigFile())
+	if err := checkfs.File(configFile, file.Options{Exists: true}); err != nil {
+		return configFile, false
+	}
+	return configFile, true
+}
+
 // NewConfiguration returns a new figtree.Plant that contains each configurable that begins with argEnvFile
 func NewConfiguration() figtree.Plant {
 	love := figtree.Options{
@@ -18,14 +34,20 @@ func NewConfiguration() figtree.Plant {
 		Germinate:         true,
 	}
 
-	defaultConfigFile := filepath.Join(env.User().HomeDir, ".config", "goenv", "config.yml")
-	configFile := env.String("AM_GO_ENV_CONFIG_FILE", defaultConfigFile)
-	if err := checkfs.File(configFile, file.Options{Exists: true}); err != nil {
-		figtree.ConfigFilePath = defaultConfigFile
+	figtree.ConfigFilePath = defaultConfigFile()
+	if configFile, ok := userConfigFile(); ok {
 		love.ConfigFile = configFile
 	}
 
+	// figtree.With calls os.Clearenv() when IgnoreEnvironment is set, so the environment is restored afterward for
+	// the env.Bool and env.String defaults below
+	environ := os.Environ()
 	figs := figtree.With(love)
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			_ = os.Setenv(k, v)
+		}
+	}
 
 	figs = figs.NewString(argEnvFile, Initial(), "Path to env file to process")
 	figs = figs.NewString(argEnv, "", "Check for an environment variable name")
@@ -38,16 +60,81 @@ func NewConfiguration() figtree.Plant {
 	figs = figs.NewBool(argVersion, false, "Show version")
 	figs = figs.NewBool(argIs, false, "Logic for checking a value in the file")
 	figs = figs.NewBool(argWrite, env.Bool(AmGoEnvAlwaysWrite, false), "Write to the -"+argEnvFile)
-	figs = figs.NewBool(argJson, env.Bool(AmGoEnvAlwaysUseJson, false), "Output in JSON format")
-	figs = figs.NewBool(argYaml, env.Bool(AmGoEnvAlwaysUseYaml, false), "Output in YAML format")
-	figs = figs.NewBool(argXml, env.Bool(AmGoEnvAlwaysUseXml, false), "Output in XML format")
-	figs = figs.NewBool(argToml, env.Bool(AmGoEnvAlwaysUseToml, false), "Output in TOML format")
-	figs = figs.NewBool(argIni, env.Bool(AmGoEnvAlwaysUseIni, false), "Output in INI format")
+	figs = formatterFlags(figs)
 	figs = figs.NewBool(argPrint, env.Bool(AmGoEnvAlwaysPrint, false), "Always print the contents of the env before exiting upon success")
 	figs = figs.NewBool(argNot, false, "Negates -has or -is")
 	figs = figs.NewBool(argInit, false, "Create the -file if it does not exist")
-	figs = figs.NewBool(argMkAll, false, "Will create all -json -xml -toml -ini -yaml output formats of -file")
-	figs = figs.NewBool(argCleanAll, false, "Remove all -json -xml -toml -ini")
+	figs = figs.NewBool(argMkAll, false, "Will create every registered output format of -file")
+	figs = figs.NewBool(argCleanAll, false, "Remove every registered output format of -file")
+	figs = figs.NewBool(argCascade, false, "Merge .env, .env.<stage>, .env.local and .env.<stage>.local in the directory of -"+argEnvFile)
+	figs = figs.NewString(argStage, env.String(EnvStage, ""), "Stage used by -"+argCascade+" such as development or production")
+	figs = figs.NewBool(argExplain, false, "Show every file and line that defines -"+argEnv+" and which definition wins")
+	figs = figs.NewString(argDiff, "", "Path to an env file to compare with -"+argEnvFile)
+	figs = figs.NewBool(argUnified, false, "Render -"+argDiff+" as a unified diff")
+	figs = figs.NewBool(argMask, env.Bool(AmGoEnvAlwaysMask, false), "Redact the values of keys matching -"+argMaskKeys+" in printed and exported output")
+	figs = figs.NewList(argMaskKeys, sensitiveKeys, "Comma separated globs of keys whose values -"+argMask+" redacts")
+	figs = figs.NewString(argMaskStyle, maskHash, "Show redacted values as a short "+maskHash+" or their "+maskLength)
+	figs = figs.NewBool(argEncrypt, false, "Encrypt the value of -"+argEnv+" in -"+argEnvFile+", or set it to the encrypted -"+argValue)
+	figs = figs.NewBool(argDecrypt, false, "Decrypt the value of -"+argEnv+", or of every key, in -"+argEnvFile)
+	figs = figs.NewBool(argRekey, false, "Encrypt every encrypted value of -"+argEnvFile+" again with -"+argNewKeyFile+" or "+EnvNewPassphrase)
+	figs = figs.NewString(argKeyFile, env.String(EnvKeyFile, defaultKeyFile()), "Key file for encrypted values, unless "+EnvPassphrase+" is set")
+	figs = figs.NewString(argNewKeyFile, "", "Key file that -"+argRekey+" encrypts values with, created when it does not exist")
+	figs = figs.NewBool(argGitIgnore, false, "Add the entries that keep env files out of git to the .gitignore of the repository")
+	figs = figs.NewString(argPerms, env.String(AmGoEnvPerms, permsWarn), "When a file holding secrets is accessible by others: "+permsWarn+", "+permsFail+" or "+permsOff)
+	figs = figs.NewBool(argCheckPerms, false, "Report the mode and owner of -"+argEnvFile+", exiting 1 when a file holding secrets is exposed")
+	figs = figs.NewBool(argFixPerms, false, "Change the mode of -"+argEnvFile+" to 0600 when it is accessible by group or others")
+	figs = figs.NewBool(argGenerate, false, "Set -"+argEnv+" to a random value when it is missing, only shown with -"+argPrint)
+	figs = figs.NewString(argKind, kindHex, "Kind of value -"+argGenerate+" creates: "+strings.Join(generateKinds, " "))
+	figs = figs.NewInt(argLength, 32, "Number of characters -"+argGenerate+" creates")
+	figs = figs.NewBool(argRotate, false, "Replace an existing value of -"+argEnv+" with -"+argGenerate)
+	figs = figs.NewBool(argScan, false, "Report values of -"+argEnvFile+" that look like real credentials, exiting 1 when any are found")
+	figs = figs.NewString(argScanAllow, scanAllowFile, "File of fingerprints and key globs that -"+argScan+" allows (defaults to next to -"+argEnvFile+")")
+	figs = figs.NewFloat64(argEntropy, 4.0, "Bits per character above which -"+argScan+" reports a token, 0 to only match known formats")
+	figs = figs.NewBool(argUndo, false, "Revert the last change goenv made to -"+argEnvFile+" using its journal")
+	figs = figs.NewBool(argRedo, false, "Apply the last change of -"+argEnvFile+" reverted by -"+argUndo+" again")
+	figs = figs.NewBool(argHistory, false, "List the changes goenv made to -"+argEnvFile+" that -"+argUndo+" can revert")
+	figs = figs.NewString(argJournalDir, env.String(AmGoEnvJournalDir, defaultJournalDir()), "Directory of the per file journals used by -"+argUndo+" and -"+argRedo)
+	figs = figs.NewBool(argDryRun, false, "Run a -"+argWrite+" and print the unified diff of every file it would change, exiting 2 when anything would change")
+	figs = figs.NewList(argReveal, []string{}, "Comma separated keys that -"+argMask+" leaves visible")
+	figs = figs.NewBool(argCheckExample, false, "List keys missing from or extra to -"+argEnvFile+" compared to -"+argExample)
+	figs = figs.NewBool(argSyncExample, false, "Append keys missing from -"+argEnvFile+" using -"+argExample+" values")
+	figs = figs.NewString(argExample, "", "Path to the example env file (defaults to "+envFileExample+" next to -"+argEnvFile+")")
+	figs = figs.NewString(argPlaceholder, "", "Value used by -"+argSyncExample+" when the example value is empty")
+	figs = figs.NewList(argMerge, []string{}, "Comma separated env files to merge into -"+argEnvFile)
+	figs = figs.NewString(argStrategy, mergeLast, "Resolve -"+argMerge+" conflicts with last, first, fail or interactive")
+	figs = figs.NewList(argMatrix, []string{}, "Comma separated env files or globs to compare key by key")
+	figs = figs.NewString(argMatrixFormat, matrixText, "Render -"+argMatrix+" as text, csv, json or markdown")
+	figs = figs.NewString(argCell, cellPresence, "Show -"+argMatrix+" cells as presence, hash or value")
+	figs = figs.NewBool(argMv, false, "Rename -"+argEnv+" to -"+argTo+" in place")
+	figs = figs.NewBool(argCp, false, "Copy -"+argEnv+" to -"+argToFile+", optionally renamed to -"+argTo)
+	figs = figs.NewString(argTo, "", "New name of the key for -"+argMv+" and -"+argCp)
+	figs = figs.NewString(argToFile, "", "Destination env file for -"+argCp)
+	figs = figs.NewString(argRecursive, "", "Directory to search for env files to run the same arguments against")
+	figs = figs.NewList(argInclude, []string{}, "Comma separated globs of files for -"+argRecursive+" or keys for -"+argCapture)
+	figs = figs.NewList(argExclude, []string{}, "Comma separated globs of files and directories skipped by -"+argRecursive+" or keys skipped by -"+argCapture)
+	figs = figs.NewInt(argJobs, runtime.NumCPU(), "Number of files -"+argRecursive+" processes at once")
+	figs = figs.NewString(argReport, reportText, "Render the -"+argRecursive+" report as text or json")
+	figs = figs.NewBool(argDrift, false, "Compare -"+argEnvFile+" with the environment of this process, -"+argPid+" or -"+argEnviron)
+	figs = figs.NewInt(argPid, 0, "Read the environment of a process from /proc/<pid>/environ")
+	figs = figs.NewString(argEnviron, "", "Path to a captured environment such as /proc/<pid>/environ or env -0 output")
+	figs = figs.NewBool(argCapture, false, "Capture the environment of this process, -"+argPid+" or -"+argEnviron+" as env file contents")
+	figs = figs.NewString(argPrefix, "", "Only -"+argCapture+" keys that start with this prefix")
+	figs = figs.NewBool(argQuote, false, "Quote values that need it when writing -"+argEnvFile)
+	figs = figs.NewString(argProfile, env.String(EnvProfile, ""), "Named profile of the "+projectFile+" to use")
+	figs = figs.NewList(argProtect, protectedFiles, "Comma separated globs of env files that need confirmation or -"+argForce+" to write")
+	figs = figs.NewList(argProtectKeys, []string{}, "Comma separated globs of keys that need confirmation or -"+argForce+" to change in any file")
+	figs = figs.NewBool(argForce, false, "Write protected files without confirmation, recording the write in the audit log")
+	figs = figs.NewBool(argAudit, false, "Print the audit log filtered by -"+argEnvFile+", -"+argEnv+", -"+argSince+" and -"+argUntil)
+	figs = figs.NewString(argAuditLog, env.String(AmGoEnvAuditLog, defaultAuditLog()), "Path of the JSON lines audit log of env file mutations")
+	figs = figs.NewString(argSince, "", "Only -"+argAudit+" entries after this RFC3339 time, date or duration ago such as 24h")
+	figs = figs.NewString(argUntil, "", "Only -"+argAudit+" entries before this RFC3339 time, date or duration ago")
+	figs = figs.NewString(argConfig, "", "Use -"+argConfig+" "+configShow+" to print the effective configuration")
+	figs = figs.NewString(argOut, "", "Path to write a single format export to")
+	figs = figs.NewString(argOutDir, "", "Directory to write -mkall exports to (defaults to the directory of -"+argEnvFile+")")
+	figs = figs.NewString(argTemplate, "", "Path to a text/template rendered with the values of -"+argEnvFile)
+	figs = figs.NewBool(argEnvSubst, false, "Render -"+argTemplate+" by replacing ${VAR} instead of using text/template")
+	figs = figs.NewBool(argStrict, false, "Fail -"+argTemplate+" when it references a missing key")
+	figs = figs.NewString(argOutName, outNameDefault, "Filename template for -mkall exports using {{.Name}} {{.Stem}} {{.Ext}} {{.Format}}")
 
 	if err := figs.Load(); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
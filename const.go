@@ -2,25 +2,43 @@ package main
 
 const (
 	EnvNeverWriteProduction        = "GOENV_NEVER_WRITE_PRODUCTION"
+	EnvStage                       = "GOENV_ENV"
+	EnvProfile                     = "GOENV_PROFILE"
+	EnvPassphrase                  = "GOENV_PASSPHRASE"
+	EnvNewPassphrase               = "GOENV_NEW_PASSPHRASE"
+	EnvKeyFile                     = "GOENV_KEY_FILE"
+	AmGoEnvConfigFile       string = "AM_GO_ENV_CONFIG_FILE"
 	AmGoEnvAlwaysWrite      string = "AM_GO_ENV_ALWAYS_WRITE"
-	AmGoEnvAlwaysUseJson    string = "AM_GO_ENV_ALWAYS_USE_JSON"
-	AmGoEnvAlwaysUseYaml    string = "AM_GO_ENV_ALWAYS_USE_YAML"
-	AmGoEnvAlwaysUseXml     string = "AM_GO_ENV_ALWAYS_USE_XML"
-	AmGoEnvAlwaysUseToml    string = "AM_GO_ENV_ALWAYS_USE_TOML"
-	AmGoEnvAlwaysUseIni     string = "AM_GO_ENV_ALWAYS_USE_INI"
+	AmGoEnvAlwaysUsePrefix  string = "AM_GO_ENV_ALWAYS_USE_"
 	AmGoEnvAlwaysPrint      string = "AM_GO_ENV_ALWAYS_PRINT"
 	AmGoEnvNeverDelete      string = "AM_GO_ENV_NEVER_DELETE"
+	AmGoEnvAuditLog         string = "AM_GO_ENV_AUDIT_LOG"
+	AmGoEnvAlwaysMask       string = "AM_GO_ENV_ALWAYS_MASK"
+	AmGoEnvPerms            string = "AM_GO_ENV_PERMS"
+	AmGoEnvJournalDir       string = "AM_GO_ENV_JOURNAL_DIR"
 
 	envFileDefault     string = ".env"
 	envFileLocal       string = ".env.local"
 	envFileDevelopment string = ".env.development"
 	envFileProduction  string = ".env.production"
+	envFileExample     string = ".env.example"
 
-	outFormatJson string = ".json"
-	outFormatYaml string = ".yaml"
-	outFormatToml string = ".toml"
-	outFormatIni  string = ".ini"
-	outFormatXml  string = ".xml"
+	projectFile   string = ".goenv.yml"
+	configShow    string = "show"
+	auditLogFile  string = "audit.log"
+	keyFile       string = "key"
+	scanAllowFile string = ".goenv-scan-allow"
+	journalFolder string = "journal"
+	journalLimit  int    = 100
+	dryRunContext int    = 3
+	dryRunChanged int    = 2
+
+	encryptPrefix     string = "ENC[aes256gcm,"
+	encryptSuffix     string = "]"
+	encryptScheme     string = "goenv aes256gcm"
+	encryptIterations int    = 600000
+
+	outNameDefault string = "{{.Name}}{{.Ext}}"
 
 	argEnvFile  string = "file"
 	argEnv      string = "env"
@@ -43,4 +61,94 @@ const (
 	argInit     string = "init"
 	argMkAll    string = "mkall"
 	argCleanAll string = "cleanall"
+	argOut      string = "out"
+	argOutDir   string = "outdir"
+	argOutName  string = "outname"
+	argTemplate string = "template"
+	argEnvSubst string = "envsubst"
+	argStrict   string = "strict"
+	argCascade  string = "cascade"
+	argStage    string = "stage"
+	argExplain  string = "explain"
+	argDiff     string = "diff"
+	argUnified  string = "unified"
+	argMask     string = "mask"
+
+	argCheckExample string = "check-example"
+	argSyncExample  string = "sync-example"
+	argExample      string = "example"
+	argPlaceholder  string = "placeholder"
+	argMerge        string = "merge"
+	argStrategy     string = "strategy"
+	argMatrix       string = "matrix"
+	argMatrixFormat string = "matrix-format"
+	argCell         string = "cell"
+	argMv           string = "mv"
+	argCp           string = "cp"
+	argTo           string = "to"
+	argToFile       string = "to-file"
+	argRecursive    string = "recursive"
+	argInclude      string = "include"
+	argExclude      string = "exclude"
+	argJobs         string = "jobs"
+	argReport       string = "report"
+	argDrift        string = "drift"
+	argPid          string = "pid"
+	argEnviron      string = "environ"
+	argCapture      string = "capture"
+	argPrefix       string = "prefix"
+	argQuote        string = "quote"
+	argProfile      string = "profile"
+	argConfig       string = "config"
+	argProtect      string = "protect"
+	argProtectKeys  string = "protect-keys"
+	argForce        string = "force"
+	argAudit        string = "audit"
+	argAuditLog     string = "audit-log"
+	argSince        string = "since"
+	argUntil        string = "until"
+	argMaskKeys     string = "mask-keys"
+	argMaskStyle    string = "mask-style"
+	argReveal       string = "reveal"
+	argEncrypt      string = "encrypt"
+	argDecrypt      string = "decrypt"
+	argRekey        string = "rekey"
+	argKeyFile      string = "key-file"
+	argNewKeyFile   string = "new-key-file"
+	argGitIgnore    string = "gitignore"
+	argPerms        string = "perms"
+	argCheckPerms   string = "check-perms"
+	argFixPerms     string = "fix-perms"
+	argGenerate     string = "generate"
+	argKind         string = "kind"
+	argLength       string = "length"
+	argRotate       string = "rotate"
+	argScan         string = "scan"
+	argScanAllow    string = "scan-allow"
+	argEntropy      string = "entropy"
+	argUndo         string = "undo"
+	argRedo         string = "redo"
+	argHistory      string = "history"
+	argJournalDir   string = "journal-dir"
+	argDryRun       string = "dry-run"
+
+	kindHex       string = "hex"
+	kindBase64    string = "base64"
+	kindBase64Url string = "base64url"
+	kindAlnum     string = "alnum"
+	kindUuid      string = "uuid"
+	kindPassword  string = "password"
+
+	alnumAlphabet   string = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	passwordSymbols string = "!%*+,-./:=?@^_~"
+
+	permsWarn string = "warn"
+	permsFail string = "fail"
+	permsOff  string = "off"
+
+	maskHash   string = "hash"
+	maskLength string = "length"
+
+	reportText string = "text"
+	reportJson string = "json"
 )
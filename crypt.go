@@ -0,0 +1,367 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/env"
+)
+
+// vault encrypts and decrypts values with a key derived per salt from a key file or a passphrase
+type vault struct {
+	secret     []byte
+	passphrase bool
+	salt       []byte
+	keys       map[string][]byte
+}
+
+// defaultKeyFile returns ~/.config/goenv/key next to the user configuration
+func defaultKeyFile() string {
+	return filepath.Join(filepath.Dir(defaultConfigFile()), keyFile)
+}
+
+// openVault loads the passphrase in passphraseEnv, or else the contents of path, creating path with a random key when
+// create is set and it does not exist
+//
+// Parameters:
+// 		path: the key file
+// 		passphraseEnv: the environment variable that holds a passphrase and takes precedence over path
+// 		create: generate path when it is missing
+func openVault(path, passphraseEnv string, create bool) (*vault, error) {
+	v := &vault{keys: make(map[string][]byte)}
+	if passphrase, ok := os.LookupEnv(passphraseEnv); ok && len(passphrase) > 0 {
+		v.secret, v.passphrase = []byte(passphrase), true
+		return v, nil
+	}
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) && create {
+		if contents, err = randomKey(); err != nil {
+			return nil, err
+		}
+		if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(path, contents, 0600); err != nil {
+			return nil, err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Created key file %s, keep a backup of it to decrypt your values\n", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("no key file %s or %s passphrase: %w", path, passphraseEnv, err)
+	}
+	v.secret = []byte(strings.TrimSpace(string(contents)))
+	if len(v.secret) < 16 {
+		return nil, fmt.Errorf("key file %s must hold at least 16 bytes", path)
+	}
+	return v, nil
+}
+
+// randomKey returns the contents of a new key file holding 32 random bytes in hex
+func randomKey() ([]byte, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	return []byte(hex.EncodeToString(random) + "\n"), nil
+}
+
+// sealingVault opens the vault that values are encrypted with like openVault, except that under -dry-run a missing
+// path is never created and a throwaway key held in memory is used instead, so that the preview still shows the lines
+// that would change
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		path: the key file
+// 		passphraseEnv: the environment variable that holds a passphrase and takes precedence over path
+// 		create: generate path when it is missing
+func sealingVault(state *stateful, path, passphraseEnv string, create bool) (*vault, error) {
+	if !create || !state.dryRun {
+		return openVault(path, passphraseEnv, create)
+	}
+	v, err := openVault(path, passphraseEnv, false)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return v, err
+	}
+	contents, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "-%s would create key file %s\n", argDryRun, path)
+	return &vault{keys: make(map[string][]byte), secret: []byte(strings.TrimSpace(string(contents)))}, nil
+}
+
+// key returns the AES-256 key for salt using PBKDF2 for a passphrase or HKDF for a key file
+func (v *vault) key(salt []byte) ([]byte, error) {
+	if k, ok := v.keys[string(salt)]; ok {
+		return k, nil
+	}
+	var k []byte
+	var err error
+	if v.passphrase {
+		k, err = pbkdf2.Key(sha256.New, string(v.secret), salt, encryptIterations, 32)
+	} else {
+		k, err = hkdf.Key(sha256.New, v.secret, salt, encryptScheme, 32)
+	}
+	if err != nil {
+		return nil, err
+	}
+	v.keys[string(salt)] = k
+	return k, nil
+}
+
+// Encrypt seals plain as ENC[aes256gcm,<base64 of salt, nonce and ciphertext>] reusing one salt per run
+func (v *vault) Encrypt(plain string) (string, error) {
+	if v.salt == nil {
+		v.salt = make([]byte, 16)
+		if _, err := rand.Read(v.salt); err != nil {
+			return "", err
+		}
+	}
+	k, err := v.key(v.salt)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := append(append(append([]byte{}, v.salt...), nonce...), gcm.Seal(nil, nonce, []byte(plain), nil)...)
+	return encryptPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptSuffix, nil
+}
+
+// Decrypt opens a value produced by Encrypt, failing when it was sealed with another key
+func (v *vault) Decrypt(value string) (string, error) {
+	encoded := strings.TrimSuffix(strings.TrimPrefix(value, encryptPrefix), encryptSuffix)
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < 16+12+16 {
+		return "", errors.New("encrypted value is too short")
+	}
+	k, err := v.key(sealed[:16])
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	plain, err := gcm.Open(nil, sealed[16:16+gcm.NonceSize()], sealed[16+gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("value was encrypted with another key")
+	}
+	return string(plain), nil
+}
+
+// isEncrypted reports whether value was sealed by Encrypt
+func isEncrypted(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, encryptPrefix) && strings.HasSuffix(value, encryptSuffix)
+}
+
+// decryptValue returns value with its encryption removed, opening the vault of state the first time it is needed
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		value: a value of argEnvFile that may be encrypted
+func decryptValue(state *stateful, value string) (string, error) {
+	if !isEncrypted(value) {
+		return value, nil
+	}
+	if state.vault == nil {
+		v, err := openVault(state.keyFile, EnvPassphrase, false)
+		if err != nil {
+			return "", err
+		}
+		state.vault = v
+	}
+	return state.vault.Decrypt(strings.TrimSpace(value))
+}
+
+// decryptEnvs returns a copy of envs with every encrypted value decrypted so that queries and exports see plain
+// values while writes keep the encrypted ones, exiting when a value cannot be decrypted
+//
+// Parameters:
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+func decryptEnvs(envs map[string]string, state *stateful) map[string]string {
+	plain := make(map[string]string, len(envs))
+	for k, v := range envs {
+		decrypted, err := decryptValue(state, v)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error decrypting %s: %v\n", k, err)
+			os.Exit(1)
+		}
+		plain[k] = decrypted
+	}
+	return plain
+}
+
+// rewriteValues replaces the value of every line of argEnvFile that change selects, keeping every other line as-is,
+// and writes the result atomically through the protection policy and the audit log when -write is set
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		operation: the flag that requested the rewrite
+// 		change: returns the new value of key, given its unquoted value, and whether the line changes
+func rewriteValues(figs figtree.Plant, state *stateful, operation string, change func(key, value string) (string, bool, error)) {
+	contents, err := os.ReadFile(state.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v\n", state.Path, err)
+		os.Exit(1)
+	}
+	lines := strings.Split(string(contents), "\n")
+	changed := make([]string, 0)
+	for i, line := range lines {
+		key, ok := lineKey(line)
+		if !ok {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(line), env.MapItemSeparator, env.MapSplitN)
+		value, replace, changeErr := change(key, unquoteValue(strings.TrimSpace(parts[1])))
+		if changeErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s failed on line %d of %s: %v\n", operation, i+1, state.Path, changeErr)
+			os.Exit(1)
+		}
+		if !replace {
+			continue
+		}
+		lines[i] = key + env.MapItemSeparator + value
+		changed = append(changed, key)
+	}
+	if len(changed) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s changed nothing in %s\n", operation, state.Path)
+		os.Exit(1)
+	}
+	rewritten := []byte(strings.Join(lines, "\n"))
+	if !state.write {
+		fmt.Print(string(rewritten))
+		fmt.Printf("The -write flag can be used to %s %s in %s\n", operation, strings.Join(changed, ", "), state.Path)
+		os.Exit(0)
+	}
+	guardWrite(state, state.Path, changed)
+	gitGuard(state, state.Path, pickValues(parseEnvs(rewritten), changed))
+	if writeErr := saveFileAtomic(state, state.Path, rewritten); writeErr != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s", state.Path, writeErr)
+		os.Exit(1)
+	}
+	auditChange(state, operation, state.Path, parseEnvs(contents), parseEnvs(rewritten))
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("%s %s in %s\n", operation, strings.Join(changed, ", "), state.Path)
+	}
+	finish(state)
+}
+
+// Encrypt replaces the value of -env in argEnvFile, or sets it to -value when provided, with its encrypted form
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env.production -encrypt -env DB_PASSWORD -write
+// 		GOENV_PASSPHRASE=... goenv -file .env.production -encrypt -env API_TOKEN -value abc123 -write
+func Encrypt(figs figtree.Plant, state *stateful) {
+	target := strings.TrimSpace(state.env)
+	if len(target) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s requires -%s\n", argEncrypt, argEnv)
+		os.Exit(1)
+	}
+	v, err := sealingVault(state, state.keyFile, EnvPassphrase, true)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argEncrypt, err)
+		os.Exit(1)
+	}
+	rewriteValues(figs, state, argEncrypt, func(key, value string) (string, bool, error) {
+		if !strings.EqualFold(key, target) {
+			return value, false, nil
+		}
+		if len(state.value) > 0 {
+			value = strings.TrimSpace(state.value)
+		} else if isEncrypted(value) {
+			return value, false, nil
+		}
+		sealed, sealErr := v.Encrypt(value)
+		return sealed, true, sealErr
+	})
+}
+
+// Decrypt replaces the encrypted value of -env, or of every key when -env is not provided, with its plain value,
+// quoted when it needs to be
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env.production -decrypt -env DB_PASSWORD -write
+func Decrypt(figs figtree.Plant, state *stateful) {
+	target := strings.TrimSpace(state.env)
+	rewriteValues(figs, state, argDecrypt, func(key, value string) (string, bool, error) {
+		if !isEncrypted(value) || (len(target) > 0 && !strings.EqualFold(key, target)) {
+			return value, false, nil
+		}
+		plain, openErr := decryptValue(state, value)
+		if openErr != nil {
+			return value, false, openErr
+		}
+		return quoteValue(plain), true, nil
+	})
+}
+
+// Rekey decrypts every encrypted value of argEnvFile with -key-file or GOENV_PASSPHRASE and encrypts it again with
+// -new-key-file or GOENV_NEW_PASSPHRASE, creating -new-key-file when it does not exist
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env.production -rekey -new-key-file ~/.config/goenv/key.next -write
+func Rekey(figs figtree.Plant, state *stateful) {
+	if _, ok := os.LookupEnv(EnvNewPassphrase); !ok && len(state.newKeyFile) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s requires -%s or %s\n", argRekey, argNewKeyFile, EnvNewPassphrase)
+		os.Exit(1)
+	}
+	next, err := sealingVault(state, state.newKeyFile, EnvNewPassphrase, len(state.newKeyFile) > 0)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argRekey, err)
+		os.Exit(1)
+	}
+	rewriteValues(figs, state, argRekey, func(key, value string) (string, bool, error) {
+		if !isEncrypted(value) {
+			return value, false, nil
+		}
+		plain, openErr := decryptValue(state, value)
+		if openErr != nil {
+			return value, false, openErr
+		}
+		sealed, sealErr := next.Encrypt(plain)
+		return sealed, true, sealErr
+	})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// diffEntry is a key that differs between argEnvFile and -diff
+	diffEntry struct {
+		Key  string `json:"key" yaml:"key" toml:"key" xml:"key" ini:"key"`
+		From string `json:"from,omitempty" yaml:"from,omitempty" toml:"from" xml:"from" ini:"from"`
+		To   string `json:"to,omitempty" yaml:"to,omitempty" toml:"to" xml:"to" ini:"to"`
+	}
+
+	// diffReport is the rendered result of -diff
+	diffReport struct {
+		From    string      `json:"from" yaml:"from" toml:"from" xml:"from" ini:"from"`
+		To      string      `json:"to" yaml:"to" toml:"to" xml:"to" ini:"to"`
+		Added   []diffEntry `json:"added" yaml:"added" toml:"added" xml:"added" ini:"added"`
+		Removed []diffEntry `json:"removed" yaml:"removed" toml:"removed" xml:"removed" ini:"removed"`
+		Changed []diffEntry `json:"changed" yaml:"changed" toml:"changed" xml:"changed" ini:"changed"`
+	}
+)
+
+// diffEnvs compares from and to where Added are keys only in to, Removed are keys only in from and Changed are keys in
+// both with different values
+//
+// Parameters:
+// 		from: map of environment variables of argEnvFile
+// 		to: map of environment variables of -diff
+// 		mask: redacts the values of sensitive keys when it is not nil
+func diffEnvs(from, to map[string]string, mask *masker) diffReport {
+	show := mask.Value
+	report := diffReport{Added: []diffEntry{}, Removed: []diffEntry{}, Changed: []diffEntry{}}
+	for _, k := range sortedKeys(from) {
+		v, ok := to[k]
+		if !ok {
+			report.Removed = append(report.Removed, diffEntry{Key: k, From: show(k, from[k])})
+		} else if v != from[k] {
+			report.Changed = append(report.Changed, diffEntry{Key: k, From: show(k, from[k]), To: show(k, v)})
+		}
+	}
+	for _, k := range sortedKeys(to) {
+		if _, ok := from[k]; !ok {
+			report.Added = append(report.Added, diffEntry{Key: k, To: show(k, to[k])})
+		}
+	}
+	return report
+}
+
+// unifiedDiff renders from and to as sorted key=value lines in a single hunk unified diff
+func unifiedDiff(fromName, toName string, from, to map[string]string, mask *masker) string {
+	show := mask.Value
+	union := make(map[string]string, len(from)+len(to))
+	for k := range from {
+		union[k] = ""
+	}
+	for k := range to {
+		union[k] = ""
+	}
+	var body strings.Builder
+	fromLines, toLines := 0, 0
+	for _, k := range sortedKeys(union) {
+		a, inFrom := from[k]
+		b, inTo := to[k]
+		switch {
+		case inFrom && inTo && a == b:
+			body.WriteString(fmt.Sprintf(" %s=%s\n", k, show(k, a)))
+			fromLines++
+			toLines++
+		default:
+			if inFrom {
+				body.WriteString(fmt.Sprintf("-%s=%s\n", k, show(k, a)))
+				fromLines++
+			}
+			if inTo {
+				body.WriteString(fmt.Sprintf("+%s=%s\n", k, show(k, b)))
+				toLines++
+			}
+		}
+	}
+	return fmt.Sprintf("--- %s\n+++ %s\n@@ -1,%d +1,%d @@\n%s", fromName, toName, fromLines, toLines, body.String())
+}
+
+// Diff compares the envs of argEnvFile with the -diff file and exits 1 when they differ
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env.staging -diff .env.production -mask
+// 		goenv -file .env.staging -diff .env.production -unified
+// 		goenv -file .env.staging -diff .env.production -json
+func Diff(figs figtree.Plant, envs map[string]string, state *stateful) {
+	other, err := readEnvFile(state.diff)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argDiff, err)
+		os.Exit(1)
+	}
+	other = decryptEnvs(other, state)
+	report := diffEnvs(envs, other, state.masker)
+	report.From, report.To = state.Path, state.diff
+	code := 0
+	if len(report.Added)+len(report.Removed)+len(report.Changed) > 0 {
+		code = 1
+	}
+
+	var output []byte
+	switch {
+	case len(state.formats) > 0 && state.formats[0].Name() == argYaml:
+		output, err = yaml.Marshal(report)
+	case len(state.formats) > 0:
+		output, err = json.MarshalIndent(report, "", "  ")
+	case state.unified:
+		if code == 1 {
+			output = []byte(unifiedDiff(state.Path, state.diff, envs, other, state.masker))
+		}
+	default:
+		var sb strings.Builder
+		for _, e := range report.Added {
+			sb.WriteString(fmt.Sprintf("+ %s=%s\n", e.Key, e.To))
+		}
+		for _, e := range report.Removed {
+			sb.WriteString(fmt.Sprintf("- %s=%s\n", e.Key, e.From))
+		}
+		for _, e := range report.Changed {
+			sb.WriteString(fmt.Sprintf("~ %s=%s -> %s\n", e.Key, e.From, e.To))
+		}
+		if *figs.Bool(argVerbose) {
+			sb.WriteString(fmt.Sprintf("%d added, %d removed, %d changed\n", len(report.Added), len(report.Removed), len(report.Changed)))
+		}
+		output = []byte(sb.String())
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argDiff, err)
+		os.Exit(1)
+	}
+	if len(output) > 0 {
+		fmt.Print(strings.TrimRight(string(output), "\n") + "\n")
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// shellNoise are variables set by shells and terminals that are skipped when comparing with or capturing a process
+var shellNoise = []string{"_", "PWD", "OLDPWD", "SHLVL", "PS1", "PS2", "COLUMNS", "LINES", "TERM", "TERM_SESSION_ID"}
+
+// driftReport is the rendered result of -drift
+type driftReport struct {
+	File       string      `json:"file" yaml:"file" toml:"file" xml:"file" ini:"file"`
+	Source     string      `json:"source" yaml:"source" toml:"source" xml:"source" ini:"source"`
+	Missing    []diffEntry `json:"missing" yaml:"missing" toml:"missing" xml:"missing" ini:"missing"`
+	Extra      []diffEntry `json:"extra" yaml:"extra" toml:"extra" xml:"extra" ini:"extra"`
+	Mismatched []diffEntry `json:"mismatched" yaml:"mismatched" toml:"mismatched" xml:"mismatched" ini:"mismatched"`
+}
+
+// parseEnviron reads KEY=value entries separated by NUL like /proc/<pid>/environ and env -0, or by newlines
+func parseEnviron(data []byte) map[string]string {
+	sep := []byte{0}
+	if !bytes.Contains(data, sep) {
+		sep = []byte{'\n'}
+	}
+	environ := make(map[string]string)
+	for _, entry := range bytes.Split(data, sep) {
+		if k, v, ok := strings.Cut(string(entry), "="); ok && len(k) > 0 {
+			environ[k] = v
+		}
+	}
+	return environ
+}
+
+// processEnviron returns the environment of -pid, the -environ dump or the current process along with its source
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+func processEnviron(state *stateful) (map[string]string, string, error) {
+	path := state.environ
+	if state.pid > 0 {
+		path = "/proc/" + strconv.Itoa(state.pid) + "/environ"
+	}
+	if len(path) > 0 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, path, err
+		}
+		return parseEnviron(data), path, nil
+	}
+	environ := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && len(k) > 0 {
+			environ[k] = v
+		}
+	}
+	return environ, "os.Environ", nil
+}
+
+// withoutNoise removes shellNoise from environ
+func withoutNoise(environ map[string]string) map[string]string {
+	for _, k := range shellNoise {
+		delete(environ, k)
+	}
+	return environ
+}
+
+// Drift compares the envs of argEnvFile with a live process environment and exits 1 when keys are missing from the
+// process or hold a different value
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env -drift -mask
+// 		goenv -file .env -drift -pid 4242 -json
+func Drift(figs figtree.Plant, envs map[string]string, state *stateful) {
+	environ, source, err := processEnviron(state)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argDrift, err)
+		os.Exit(1)
+	}
+	diff := diffEnvs(envs, withoutNoise(environ), state.masker)
+	report := driftReport{File: state.Path, Source: source, Missing: diff.Removed, Extra: diff.Added, Mismatched: diff.Changed}
+	code := 0
+	if len(report.Missing)+len(report.Mismatched) > 0 {
+		code = 1
+	}
+	if len(state.formats) > 0 {
+		var output []byte
+		if state.formats[0].Name() == argYaml {
+			output, err = yaml.Marshal(report)
+		} else {
+			output, err = json.MarshalIndent(report, "", "  ")
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argDrift, err)
+			os.Exit(1)
+		}
+		fmt.Println(strings.TrimRight(string(output), "\n"))
+		os.Exit(code)
+	}
+	for _, e := range report.Missing {
+		fmt.Printf("missing  %s=%s\n", e.Key, e.From)
+	}
+	for _, e := range report.Mismatched {
+		fmt.Printf("mismatch %s=%s (process has %s)\n", e.Key, e.From, e.To)
+	}
+	for _, e := range report.Extra {
+		fmt.Printf("extra    %s=%s\n", e.Key, e.To)
+	}
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("%d missing, %d mismatched, %d extra in %s\n", len(report.Missing), len(report.Mismatched), len(report.Extra), source)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreimerlescu/goenv/env"
+)
+
+// diffOp is a line of a unified diff where Kind is ' ', '-' or '+'
+type diffOp struct {
+	Kind byte
+	Text string
+}
+
+// diffLines returns the edit script from a to b using the longest common subsequence of their lines
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// splitLines splits contents into lines without the empty line that follows a trailing newline
+func splitLines(contents []byte) []string {
+	if len(contents) == 0 {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+}
+
+// unifiedLines renders the line diff of a and b as a unified diff with dryRunContext lines around each change, or
+// an empty string when they are equal
+//
+// Parameters:
+// 		fromName: label of a such as the path or /dev/null
+// 		toName: label of b
+// 		a: the current lines
+// 		b: the lines that would be written
+func unifiedLines(fromName, toName string, a, b []string) string {
+	ops := diffLines(a, b)
+	changed := make([]int, 0)
+	for i, op := range ops {
+		if op.Kind != ' ' {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+	for c := 0; c < len(changed); {
+		start := max(changed[c]-dryRunContext, 0)
+		end := changed[c]
+		for c < len(changed) && changed[c] <= end+2*dryRunContext {
+			end = changed[c]
+			c++
+		}
+		end = min(end+dryRunContext, len(ops)-1)
+		fromLine, toLine := 1, 1
+		for _, op := range ops[:start] {
+			if op.Kind != '+' {
+				fromLine++
+			}
+			if op.Kind != '-' {
+				toLine++
+			}
+		}
+		fromCount, toCount := 0, 0
+		var body strings.Builder
+		for _, op := range ops[start : end+1] {
+			if op.Kind != '+' {
+				fromCount++
+			}
+			if op.Kind != '-' {
+				toCount++
+			}
+			body.WriteString(string(op.Kind) + op.Text + "\n")
+		}
+		if fromCount == 0 {
+			fromLine--
+		}
+		if toCount == 0 {
+			toLine--
+		}
+		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount))
+		sb.WriteString(body.String())
+	}
+	return sb.String()
+}
+
+// maskLines redacts the values of sensitive keys on the lines of an env file when -mask is set
+func maskLines(state *stateful, lines []string) []string {
+	masked := make([]string, len(lines))
+	for i, line := range lines {
+		masked[i] = line
+		key, ok := lineKey(line)
+		if !ok || !state.masker.Sensitive(key) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(line), env.MapItemSeparator, env.MapSplitN)
+		masked[i] = key + env.MapItemSeparator + state.masker.Value(key, unquoteValue(strings.TrimSpace(parts[1])))
+	}
+	return masked
+}
+
+// previewFile prints the unified diff between path and the contents a write would leave in it, where nil contents
+// removes path, and remembers that -dry-run found a change
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		path: the file that would be written
+// 		contents: the contents that would be written
+func previewFile(state *stateful, path string, contents []byte) {
+	fromName, toName := path, path
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fromName = os.DevNull
+	} else if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v\n", path, err)
+		os.Exit(1)
+	}
+	if contents == nil {
+		toName = os.DevNull
+	}
+	diff := unifiedLines(fromName, toName, maskLines(state, splitLines(current)), maskLines(state, splitLines(contents)))
+	if len(diff) == 0 {
+		return
+	}
+	fmt.Print(diff)
+	state.pending = true
+}
+
+// saveFile writes contents to path with perm, or only previews the write with -dry-run
+func saveFile(state *stateful, path string, contents []byte, perm os.FileMode) error {
+	if state.dryRun {
+		previewFile(state, path, contents)
+		return nil
+	}
+	return os.WriteFile(path, contents, perm)
+}
+
+// saveFileAtomic replaces path with contents using writeFileAtomic, or only previews the write with -dry-run
+func saveFileAtomic(state *stateful, path string, contents []byte) error {
+	if state.dryRun {
+		previewFile(state, path, contents)
+		return nil
+	}
+	return writeFileAtomic(path, contents)
+}
+
+// finish exits after a write, with dryRunChanged when -dry-run found that the write would change a file
+func finish(state *stateful) {
+	if state.dryRun && state.pending {
+		os.Exit(dryRunChanged)
+	}
+	os.Exit(0)
+}
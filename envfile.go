@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreimerlescu/goenv/env"
+	"github.com/andreimerlescu/goenv/format"
+)
+
+// parseEnvs reads key=value lines from contents using the same rules as Run where later lines override earlier ones
+func parseEnvs(contents []byte) map[string]string {
+	envs := make(map[string]string)
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 3 {
+			continue
+		}
+		parts := strings.SplitN(line, env.MapItemSeparator, env.MapSplitN)
+		if len(parts) != 2 {
+			continue
+		}
+		envs[strings.TrimSpace(parts[0])] = unquoteValue(strings.TrimSpace(parts[1]))
+	}
+	return envs
+}
+
+// readEnvFile loads the envs of path, decoding it with a registered Formatter when its extension belongs to one
+// that implements Decoder such as .json or .ini, and otherwise expanding its include directives
+//
+// Parameters:
+// 		path: the env file or export to read
+func readEnvFile(path string) (map[string]string, error) {
+	if f, ok := format.For(filepath.Ext(path)); ok {
+		if decoder, ok := f.(format.Decoder); ok {
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			return decoder.Decode(contents)
+		}
+	}
+	lines, err := readSources([]string{path})
+	if err != nil {
+		return nil, err
+	}
+	return parseEnvs(joinSources(lines)), nil
+}
+
+// quoteValue wraps value in double quotes, escaping \\ " $ ` and line breaks, when it contains anything other than
+// characters that are safe unquoted in a dotenv file
+func quoteValue(value string) string {
+	safe := true
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-./:@%+,=", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// unquoteValue reverses quoteValue, so a value wrapped in double quotes loses them along with its escapes and a value
+// wrapped in single quotes is taken literally, while anything else is returned as-is
+func unquoteValue(value string) string {
+	if len(value) < 2 || value[0] != value[len(value)-1] {
+		return value
+	}
+	switch value[0] {
+	case '\'':
+		return value[1 : len(value)-1]
+	case '"':
+		replacer := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\$`, "$", "\\`", "`", `\n`, "\n", `\r`, "\r")
+		return replacer.Replace(value[1 : len(value)-1])
+	}
+	return value
+}
+
+// writeFileAtomic replaces path with contents by writing a temporary file in the same directory and renaming it over
+// path, keeping the mode of an existing path and creating a new one with 0600 since env files hold secrets
+//
+// Parameters:
+// 		path: the file to replace
+// 		contents: the new contents of path
+func writeFileAtomic(path string, contents []byte) error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = tmp.Write(contents); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andreimerlescu/figtree/v2"
+)
+
+// exampleReport is the rendered result of -check-example
+type exampleReport struct {
+	File    string   `json:"file" yaml:"file" toml:"file" xml:"file" ini:"file"`
+	Example string   `json:"example" yaml:"example" toml:"example" xml:"example" ini:"example"`
+	Missing []string `json:"missing" yaml:"missing" toml:"missing" xml:"missing" ini:"missing"`
+	Extra   []string `json:"extra" yaml:"extra" toml:"extra" xml:"extra" ini:"extra"`
+}
+
+// examplePath returns -example or the envFileExample next to argEnvFile
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+func examplePath(state *stateful) string {
+	if len(state.example) > 0 {
+		return state.example
+	}
+	return filepath.Join(filepath.Dir(state.Path), envFileExample)
+}
+
+// CheckExample lists the keys of the example that are missing from argEnvFile and the keys of argEnvFile that the
+// example does not define, exiting 1 when keys are missing
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+func CheckExample(figs figtree.Plant, envs map[string]string, state *stateful) {
+	path := examplePath(state)
+	example, err := readEnvFile(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argCheckExample, err)
+		os.Exit(1)
+	}
+	report := exampleReport{File: state.Path, Example: path, Missing: []string{}, Extra: []string{}}
+	for _, k := range sortedKeys(example) {
+		if _, ok := envs[k]; !ok {
+			report.Missing = append(report.Missing, k)
+		}
+	}
+	for _, k := range sortedKeys(envs) {
+		if _, ok := example[k]; !ok {
+			report.Extra = append(report.Extra, k)
+		}
+	}
+	code := 0
+	if len(report.Missing) > 0 {
+		code = 1
+	}
+	if len(state.formats) > 0 {
+		output, jsonErr := json.MarshalIndent(report, "", "  ")
+		if jsonErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argCheckExample, jsonErr)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		os.Exit(code)
+	}
+	for _, k := range report.Missing {
+		fmt.Printf("missing %s\n", k)
+	}
+	for _, k := range report.Extra {
+		fmt.Printf("extra   %s\n", k)
+	}
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("%d missing, %d extra compared to %s\n", len(report.Missing), len(report.Extra), path)
+	}
+	os.Exit(code)
+}
+
+// SyncExample appends the keys of the example that are missing from argEnvFile using their example values or
+// -placeholder when the example value is empty, leaving every existing line of argEnvFile untouched
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+func SyncExample(figs figtree.Plant, envs map[string]string, state *stateful) {
+	path := examplePath(state)
+	example, err := readEnvFile(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argSyncExample, err)
+		os.Exit(1)
+	}
+	var missing bytes.Buffer
+	added := make([]string, 0)
+	synced := make(map[string]string, len(envs))
+	for k, v := range envs {
+		synced[k] = v
+	}
+	for _, k := range sortedKeys(example) {
+		if _, ok := envs[k]; ok {
+			continue
+		}
+		added = append(added, k)
+		v := example[k]
+		if len(v) == 0 {
+			v = state.placeholder
+		}
+		missing.WriteString(fmt.Sprintf("%s=%s\n", k, v))
+		synced[k] = v
+	}
+	if missing.Len() == 0 {
+		if *figs.Bool(argVerbose) {
+			fmt.Printf("%s has every key of %s\n", state.Path, path)
+		}
+		os.Exit(0)
+	}
+	if !state.write {
+		fmt.Print(missing.String())
+		fmt.Printf("The -write flag can be used to append these to %s\n", state.Path)
+		os.Exit(0)
+	}
+	guardWrite(state, state.Path, added)
+	gitGuard(state, state.Path, pickValues(synced, added))
+	contents, err := os.ReadFile(state.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v", state.Path, err)
+		os.Exit(1)
+	}
+	if len(contents) > 0 && contents[len(contents)-1] != '\n' {
+		contents = append(contents, '\n')
+	}
+	contents = append(contents, missing.Bytes()...)
+	if writeErr := saveFile(state, state.Path, contents, 0600); writeErr != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s", state.Path, writeErr)
+		os.Exit(1)
+	}
+	auditChange(state, argSyncExample, state.Path, envs, synced)
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("Appended to %s:\n%s", state.Path, missing.String())
+	}
+	finish(state)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/env"
+)
+
+type (
+	// definition is a single line of an env file that assigns a key
+	definition struct {
+		File      string `json:"file" yaml:"file" toml:"file" xml:"file" ini:"file"`
+		Line      int    `json:"line" yaml:"line" toml:"line" xml:"line" ini:"line"`
+		Key       string `json:"key" yaml:"key" toml:"key" xml:"key" ini:"key"`
+		Value     string `json:"value" yaml:"value" toml:"value" xml:"value" ini:"value"`
+		Effective bool   `json:"effective" yaml:"effective" toml:"effective" xml:"effective" ini:"effective"`
+	}
+
+	// explanation is the rendered result of -explain
+	explanation struct {
+		Key         string       `json:"key" yaml:"key" toml:"key" xml:"key" ini:"key"`
+		Value       string       `json:"value" yaml:"value" toml:"value" xml:"value" ini:"value"`
+		Defined     bool         `json:"defined" yaml:"defined" toml:"defined" xml:"defined" ini:"defined"`
+		Definitions []definition `json:"definitions" yaml:"definitions" toml:"definitions" xml:"definitions" ini:"definitions"`
+	}
+)
+
+// sourceFiles returns the env files that make up the view of argEnvFile ordered from lowest to highest priority
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+func sourceFiles(state *stateful) []string {
+	if state.cascade {
+		return state.layers
+	}
+	return []string{state.Path}
+}
+
+// definitionsOf scans each file and the files it includes for lines that assign key using the same rules as Run and
+// marks the last one effective
+//
+// Parameters:
+// 		files: env files ordered from lowest to highest priority
+// 		key: the key to look for, compared case-insensitively like -has
+func definitionsOf(files []string, key string) ([]definition, error) {
+	key = strings.TrimSpace(key)
+	found := make([]definition, 0)
+	sources, err := readSources(files)
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		line := strings.TrimSpace(source.Text)
+		if len(line) < 3 {
+			continue
+		}
+		parts := strings.SplitN(line, env.MapItemSeparator, env.MapSplitN)
+		if len(parts) != 2 || !strings.EqualFold(strings.TrimSpace(parts[0]), key) {
+			continue
+		}
+		found = append(found, definition{
+			File:  source.File,
+			Line:  source.Line,
+			Key:   strings.TrimSpace(parts[0]),
+			Value: unquoteValue(strings.TrimSpace(parts[1])),
+		})
+	}
+	if len(found) > 0 {
+		found[len(found)-1].Effective = true
+	}
+	return found, nil
+}
+
+// Explain prints every definition of -env across the sources of argEnvFile, which one wins and what it shadows
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -explain -env DATABASE_URL
+// 		goenv -cascade -explain -env DATABASE_URL -json
+func Explain(figs figtree.Plant, state *stateful) {
+	if len(strings.TrimSpace(state.env)) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s requires -%s\n", argExplain, argEnv)
+		os.Exit(1)
+	}
+	defs, err := definitionsOf(sourceFiles(state), state.env)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argExplain, err)
+		os.Exit(1)
+	}
+	result := explanation{Key: strings.TrimSpace(state.env), Defined: len(defs) > 0, Definitions: defs}
+	for i := range defs {
+		defs[i].Value = state.masker.Value(defs[i].Key, defs[i].Value)
+	}
+	if result.Defined {
+		result.Key, result.Value = defs[len(defs)-1].Key, defs[len(defs)-1].Value
+	}
+
+	code := 0
+	if !result.Defined {
+		code = 1
+	}
+	if len(state.formats) > 0 {
+		output, jsonErr := json.MarshalIndent(result, "", "  ")
+		if jsonErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argExplain, jsonErr)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		os.Exit(code)
+	}
+
+	if !result.Defined {
+		fmt.Printf("%s is not defined in %s\n", result.Key, strings.Join(sourceFiles(state), ", "))
+		os.Exit(code)
+	}
+	winner := defs[len(defs)-1]
+	fmt.Printf("%s=%s\n", winner.Key, winner.Value)
+	fmt.Printf("  wins    %s:%d\n", winner.File, winner.Line)
+	for i := len(defs) - 2; i >= 0; i-- {
+		fmt.Printf("  shadows %s:%d %s=%s\n", defs[i].File, defs[i].Line, defs[i].Key, defs[i].Value)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/env"
+	"github.com/andreimerlescu/goenv/format"
+)
+
+// formatterFlags registers a -<Name> bool on figs for every Formatter that defaults to AM_GO_ENV_ALWAYS_USE_<NAME>
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+func formatterFlags(figs figtree.Plant) figtree.Plant {
+	for _, f := range format.All() {
+		upper := strings.ToUpper(f.Name())
+		figs = figs.NewBool(f.Name(), env.Bool(AmGoEnvAlwaysUsePrefix+upper, false), "Output in "+upper+" format")
+	}
+	return figs
+}
+
+// selectedFormatters returns each Formatter whose -<Name> flag is enabled on figs
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+func selectedFormatters(figs figtree.Plant) []format.Formatter {
+	selected := make([]format.Formatter, 0, 1)
+	for _, f := range format.All() {
+		if on := figs.Bool(f.Name()); on != nil && *on {
+			selected = append(selected, f)
+		}
+	}
+	return selected
+}
+
+// processFormat renders the argEnvFile with the Formatter f
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		f: the Formatter to render with
+func processFormat(figs figtree.Plant, envs map[string]string, state *stateful, f format.Formatter) {
+	output, err := f.Encode(envs)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error rendering %s: %v\n", f.Name(), err)
+		os.Exit(1)
+	}
+	writeProcessed(figs, output, f.Extension(), state)
+}
+
+// sortedKeys returns the keys of envs in ascending order so exports are stable
+func sortedKeys(envs map[string]string) []string {
+	return format.SortedKeys(envs)
+}
@@ -0,0 +1,101 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type (
+	// jsonFormatter renders envs as a JSON object
+	jsonFormatter struct{}
+	// iniFormatter renders envs as the [default] section of an INI file
+	iniFormatter struct{}
+	// tomlFormatter renders envs as TOML
+	tomlFormatter struct{}
+	// yamlFormatter renders envs as a YAML document
+	yamlFormatter struct{}
+	// xmlFormatter renders envs as the children of an <env> element
+	xmlFormatter struct{}
+)
+
+func (jsonFormatter) Name() string      { return "json" }
+func (jsonFormatter) Extension() string { return ".json" }
+func (jsonFormatter) Encode(envs map[string]string) ([]byte, error) {
+	return json.MarshalIndent(envs, "", "  ")
+}
+func (jsonFormatter) Decode(data []byte) (map[string]string, error) {
+	envs := make(map[string]string)
+	err := json.Unmarshal(data, &envs)
+	return envs, err
+}
+
+func (iniFormatter) Name() string      { return "ini" }
+func (iniFormatter) Extension() string { return ".ini" }
+func (iniFormatter) Encode(envs map[string]string) ([]byte, error) {
+	var ini bytes.Buffer
+	ini.WriteString("[default]\n")
+	for _, e := range SortedKeys(envs) {
+		ini.WriteString(fmt.Sprintf("%s = %s\n", strings.TrimSpace(e), strings.TrimSpace(envs[e])))
+	}
+	return ini.Bytes(), nil
+}
+func (iniFormatter) Decode(data []byte) (map[string]string, error) {
+	envs := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "[") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid ini line %q", line)
+		}
+		envs[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return envs, nil
+}
+
+func (tomlFormatter) Name() string      { return "toml" }
+func (tomlFormatter) Extension() string { return ".toml" }
+func (tomlFormatter) Encode(envs map[string]string) ([]byte, error) {
+	var toml bytes.Buffer
+	for _, e := range SortedKeys(envs) {
+		toml.WriteString(fmt.Sprintf("%s: \"%s\" \n", strings.TrimSpace(e), strings.TrimSpace(envs[e])))
+	}
+	return toml.Bytes(), nil
+}
+
+func (yamlFormatter) Name() string      { return "yaml" }
+func (yamlFormatter) Extension() string { return ".yaml" }
+func (yamlFormatter) Encode(envs map[string]string) ([]byte, error) {
+	var yaml bytes.Buffer
+	yaml.WriteString("---\n")
+	for _, e := range SortedKeys(envs) {
+		yaml.WriteString(fmt.Sprintf("%s: \"%s\" \n", strings.TrimSpace(e), strings.TrimSpace(envs[e])))
+	}
+	return yaml.Bytes(), nil
+}
+
+func (xmlFormatter) Name() string      { return "xml" }
+func (xmlFormatter) Extension() string { return ".xml" }
+func (xmlFormatter) Encode(envs map[string]string) ([]byte, error) {
+	var xml bytes.Buffer
+	xml.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	xml.WriteString("<env>\n")
+	for _, e := range SortedKeys(envs) {
+		i := "   "
+		e, v := strings.TrimSpace(e), strings.TrimSpace(envs[e])
+
+		xml.WriteString(i + "<")
+		xml.WriteString(e)
+		xml.WriteString(">")
+		xml.WriteString(v)
+		xml.WriteString("</")
+		xml.WriteString(e)
+		xml.WriteString(">\n")
+	}
+	xml.WriteString("</env>\n")
+	return xml.Bytes(), nil
+}
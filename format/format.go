@@ -0,0 +1,74 @@
+package format
+
+import (
+	"sort"
+	"strings"
+)
+
+// Formatter renders the envs of an env file into an export format. Each registered Formatter becomes a -<Name> flag
+// of goenv, participates in the checks that prevent combining formats and is included in -mkall and -cleanall.
+type Formatter interface {
+	// Name is the flag name of the format such as "json"
+	Name() string
+	// Extension is appended to the -file when writing the export such as ".json"
+	Extension() string
+	// Encode renders the envs into the format
+	Encode(envs map[string]string) ([]byte, error)
+}
+
+// Decoder is optionally implemented by a Formatter that can read its own exports back into envs
+type Decoder interface {
+	// Decode parses data that was rendered by Encode back into envs
+	Decode(data []byte) (map[string]string, error)
+}
+
+// registry holds the built-in formats followed by every Formatter added with Register
+var registry = []Formatter{
+	jsonFormatter{},
+	iniFormatter{},
+	yamlFormatter{},
+	tomlFormatter{},
+	xmlFormatter{},
+}
+
+// Register adds f to the registry of export formats, replacing any Formatter that uses the same Name. It must be
+// called before goenv builds its flags, which is the case from an init() func of a package that main imports.
+//
+// Example:
+// 		func init() {
+// 			format.Register(dotenvFormatter{})
+// 		}
+func Register(f Formatter) {
+	for i, existing := range registry {
+		if existing.Name() == f.Name() {
+			registry[i] = f
+			return
+		}
+	}
+	registry = append(registry, f)
+}
+
+// All returns every registered Formatter in the order they were registered
+func All() []Formatter {
+	return registry
+}
+
+// For returns the registered Formatter by its Name or Extension
+func For(nameOrExt string) (Formatter, bool) {
+	for _, f := range registry {
+		if strings.EqualFold(f.Name(), nameOrExt) || strings.EqualFold(f.Extension(), nameOrExt) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// SortedKeys returns the keys of envs in ascending order so exports are stable
+func SortedKeys(envs map[string]string) []string {
+	keys := make([]string, 0, len(envs))
+	for k := range envs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andreimerlescu/goenv/format"
+)
+
+// dotenvFormatter is a custom export format registered the way a package imported by main would register it
+type dotenvFormatter struct{}
+
+func (dotenvFormatter) Name() string      { return "dotenv" }
+func (dotenvFormatter) Extension() string { return ".dotenv" }
+func (dotenvFormatter) Encode(envs map[string]string) ([]byte, error) {
+	var bb bytes.Buffer
+	for _, k := range format.SortedKeys(envs) {
+		bb.WriteString(fmt.Sprintf("export %s=%q\n", k, envs[k]))
+	}
+	return bb.Bytes(), nil
+}
+
+func init() {
+	format.Register(dotenvFormatter{})
+}
+
+// TestMain runs goenv itself when the test binary is started by runGoenv, so that the custom format registered
+// above is exercised through the real flags
+func TestMain(m *testing.M) {
+	if os.Getenv("GOENV_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runGoenv runs the test binary as goenv with args inside dir, keeping the audit log, journal and user configuration
+// out of the home directory, and returns its combined output and exit code
+func runGoenv(t *testing.T, dir string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GOENV_TEST_MAIN=1",
+		AmGoEnvConfigFile+"="+filepath.Join(dir, "config.yml"),
+		AmGoEnvAuditLog+"="+filepath.Join(dir, "audit.log"),
+		AmGoEnvJournalDir+"="+filepath.Join(dir, "journal"),
+	)
+	output, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return string(output), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("running goenv %s: %v", strings.Join(args, " "), err)
+	}
+	return string(output), 0
+}
+
+func TestRegisteredFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.env"), []byte("HOST=localhost\nPORT=8080\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	export := filepath.Join(dir, "app.env.dotenv")
+
+	if f, ok := format.For(".dotenv"); !ok || f.Name() != "dotenv" {
+		t.Fatalf("format.For(.dotenv) = %v, %v", f, ok)
+	}
+
+	output, code := runGoenv(t, dir, "-file", "app.env", "-dotenv")
+	if code != 0 || !strings.Contains(output, `export HOST="localhost"`) {
+		t.Fatalf("-dotenv exited %d with %q", code, output)
+	}
+
+	output, code = runGoenv(t, dir, "-file", "app.env", "-dotenv", "-json")
+	if code != 1 || !strings.Contains(output, "-dotenv") {
+		t.Fatalf("-dotenv -json exited %d with %q, want the formats to be mutually exclusive", code, output)
+	}
+
+	if output, code = runGoenv(t, dir, "-file", "app.env", "-mkall", "-write"); code != 0 {
+		t.Fatalf("-mkall -write exited %d with %q", code, output)
+	}
+	contents, err := os.ReadFile(export)
+	if err != nil {
+		t.Fatalf("-mkall did not write %s: %v", export, err)
+	}
+	if want := "export HOST=\"localhost\"\nexport PORT=\"8080\"\n"; string(contents) != want {
+		t.Fatalf("%s = %q, want %q", export, contents, want)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "app.env.json")); err != nil {
+		t.Fatalf("-mkall did not write the built-in formats: %v", err)
+	}
+
+	if output, code = runGoenv(t, dir, "-file", "app.env", "-cleanall", "-write"); code != 0 {
+		t.Fatalf("-cleanall -write exited %d with %q", code, output)
+	}
+	if _, err = os.Stat(export); !os.IsNotExist(err) {
+		t.Fatalf("-cleanall left %s behind: %v", export, err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+)
+
+// generateKinds are the values accepted by -kind
+var generateKinds = []string{kindHex, kindBase64, kindBase64Url, kindAlnum, kindUuid, kindPassword}
+
+// randomFrom returns n characters picked uniformly from alphabet using crypto/rand
+func randomFrom(alphabet string, n int) (string, error) {
+	var sb strings.Builder
+	limit := big.NewInt(int64(len(alphabet)))
+	for i := 0; i < n; i++ {
+		pick, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(alphabet[pick.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// randomBytes returns n bytes from crypto/rand
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+// generateSecret returns a random value of kind that is length characters long, except for kindUuid which is always
+// a version 4 UUID
+//
+// Parameters:
+// 		kind: one of generateKinds
+// 		length: the number of characters of the value
+func generateSecret(kind string, length int) (string, error) {
+	switch kind {
+	case kindHex:
+		b, err := randomBytes((length + 1) / 2)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b)[:length], nil
+	case kindBase64, kindBase64Url:
+		b, err := randomBytes(length)
+		if err != nil {
+			return "", err
+		}
+		encoding := base64.RawStdEncoding
+		if kind == kindBase64Url {
+			encoding = base64.RawURLEncoding
+		}
+		return encoding.EncodeToString(b)[:length], nil
+	case kindAlnum:
+		return randomFrom(alnumAlphabet, length)
+	case kindUuid:
+		b, err := randomBytes(16)
+		if err != nil {
+			return "", err
+		}
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+	case kindPassword:
+		if length < 8 {
+			return "", fmt.Errorf("-%s %s needs a -%s of at least 8", argKind, kindPassword, argLength)
+		}
+		for {
+			password, err := randomFrom(alnumAlphabet+passwordSymbols, length)
+			if err != nil {
+				return "", err
+			}
+			// every class is required so that password policies accept the value
+			if strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz") &&
+				strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") &&
+				strings.ContainsAny(password, "0123456789") &&
+				strings.ContainsAny(password, passwordSymbols) {
+				return password, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("-%s must be one of %s", argKind, strings.Join(generateKinds, " "))
+}
+
+// Generate sets -env in envs to a random value of -kind and -length when it is missing, or always with -rotate, so
+// that Result saves it through -write with the usual protection, git, audit and encryption handling. The value is
+// only shown with -print.
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env -generate -env SESSION_SECRET -kind base64url -length 48 -write
+// 		goenv -file .env.production -generate -env DB_PASSWORD -kind password -rotate -encrypt -write
+func Generate(figs figtree.Plant, envs map[string]string, state *stateful) {
+	target := strings.TrimSpace(state.env)
+	if len(target) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s requires -%s\n", argGenerate, argEnv)
+		os.Exit(1)
+	}
+	key := target
+	for k := range envs {
+		if strings.EqualFold(k, target) {
+			key = k
+			break
+		}
+	}
+	if current, exists := envs[key]; exists && len(current) > 0 && !state.rotate {
+		_, _ = fmt.Fprintf(os.Stderr, "%s already has a value, use -%s to replace it\n", key, argRotate)
+		os.Exit(0)
+	}
+	value, err := generateSecret(state.kind, state.length)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argGenerate, err)
+		os.Exit(1)
+	}
+	if state.printer {
+		fmt.Printf("%s=%s\n", key, value)
+		state.printer = false
+	}
+	if state.encrypt {
+		v, vaultErr := sealingVault(state, state.keyFile, EnvPassphrase, true)
+		if vaultErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argEncrypt, vaultErr)
+			os.Exit(1)
+		}
+		if value, err = v.Encrypt(value); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argEncrypt, err)
+			os.Exit(1)
+		}
+	}
+	if !state.write {
+		fmt.Printf("The -write flag can be used to save the generated %s to %s\n", key, state.Path)
+		os.Exit(0)
+	}
+	envs[key] = value
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("Generated %s %s of %s\n", state.kind, key, state.Path)
+	}
+}
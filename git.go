@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+)
+
+// gitRepo is the git repository surrounding an env file, read from disk without the git binary
+type gitRepo struct {
+	Root string
+	Dir  string
+}
+
+// ignoreRule is a single pattern of a .gitignore or .git/info/exclude file
+type ignoreRule struct {
+	base    string
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// findGitRepo walks up from dir until it finds a .git directory, or a .git file pointing at one for worktrees
+//
+// Parameters:
+// 		dir: the directory to start from, usually the directory of argEnvFile
+func findGitRepo(dir string) (gitRepo, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return gitRepo{}, false
+	}
+	for {
+		candidate := filepath.Join(dir, ".git")
+		if info, statErr := os.Stat(candidate); statErr == nil {
+			if info.IsDir() {
+				return gitRepo{Root: dir, Dir: candidate}, true
+			}
+			contents, readErr := os.ReadFile(candidate)
+			if gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(contents)), "gitdir:"); readErr == nil && ok {
+				gitDir = strings.TrimSpace(gitDir)
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(dir, gitDir)
+				}
+				return gitRepo{Root: dir, Dir: gitDir}, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return gitRepo{}, false
+		}
+		dir = parent
+	}
+}
+
+// relative returns path relative to the root of the repository using forward slashes like the index
+func (r gitRepo) relative(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(r.Root, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Tracked reports whether path is staged in the index of the repository, reading index versions 2, 3 and 4
+func (r gitRepo) Tracked(path string) (bool, error) {
+	rel, err := r.relative(path)
+	if err != nil {
+		return false, err
+	}
+	index, err := os.ReadFile(filepath.Join(r.Dir, "index"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(index) < 12 || string(index[:4]) != "DIRC" {
+		return false, errors.New("unrecognized git index")
+	}
+	version := binary.BigEndian.Uint32(index[4:8])
+	count := binary.BigEndian.Uint32(index[8:12])
+	hashSize := 20
+	if config, configErr := os.ReadFile(filepath.Join(r.Dir, "config")); configErr == nil &&
+		regexp.MustCompile(`(?m)^\s*objectformat\s*=\s*sha256\s*$`).Match(config) {
+		hashSize = 32
+	}
+	offset, previous := 12, ""
+	for i := uint32(0); i < count; i++ {
+		start := offset
+		offset += 40 + hashSize
+		if offset+2 > len(index) {
+			return false, errors.New("truncated git index")
+		}
+		flags := binary.BigEndian.Uint16(index[offset : offset+2])
+		offset += 2
+		if version >= 3 && flags&0x4000 != 0 {
+			offset += 2
+		}
+		var name string
+		if version == 4 {
+			strip, n := indexVarint(index[offset:])
+			offset += n
+			end := bytes.IndexByte(index[offset:], 0)
+			if end < 0 || int(strip) > len(previous) {
+				return false, errors.New("truncated git index")
+			}
+			name = previous[:len(previous)-int(strip)] + string(index[offset:offset+end])
+			offset += end + 1
+		} else {
+			end := bytes.IndexByte(index[offset:], 0)
+			if end < 0 {
+				return false, errors.New("truncated git index")
+			}
+			name = string(index[offset : offset+end])
+			offset = start + ((offset - start + end + 8) &^ 7)
+		}
+		if name == rel {
+			return true, nil
+		}
+		previous = name
+	}
+	return false, nil
+}
+
+// indexVarint decodes the offset varint used by index version 4 and returns it with the number of bytes read
+func indexVarint(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	value, n := uint64(b[0]&0x7f), 1
+	for b[n-1]&0x80 != 0 && n < len(b) {
+		value = ((value + 1) << 7) | uint64(b[n]&0x7f)
+		n++
+	}
+	return value, n
+}
+
+// Ignored reports whether path is ignored by .git/info/exclude or a .gitignore between the root and its directory
+func (r gitRepo) Ignored(path string) (bool, error) {
+	rel, err := r.relative(path)
+	if err != nil {
+		return false, err
+	}
+	rules, err := readIgnoreRules(filepath.Join(r.Dir, "info", "exclude"), "")
+	if err != nil {
+		return false, err
+	}
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		base := strings.Join(parts[:i], "/")
+		more, readErr := readIgnoreRules(filepath.Join(r.Root, filepath.FromSlash(base), ".gitignore"), base)
+		if readErr != nil {
+			return false, readErr
+		}
+		rules = append(rules, more...)
+		// git does not look inside an ignored directory, so its contents stay ignored
+		if i < len(parts)-1 && matchIgnore(rules, strings.Join(parts[:i+1], "/"), true) {
+			return true, nil
+		}
+	}
+	return matchIgnore(rules, rel, false), nil
+}
+
+// readIgnoreRules parses the patterns of a .gitignore found in the repository directory base, if it exists
+func readIgnoreRules(path, base string) ([]ignoreRule, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	rules := make([]ignoreRule, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// parseIgnoreRule parses a line of a .gitignore in the repository directory base, skipping blanks and comments
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if len(line) == 0 || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate, line = true, line[1:]
+	}
+	line = strings.TrimPrefix(line, `\`)
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly, line = true, strings.TrimSuffix(line, "/")
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expr := globRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "(^|/)" + expr + "$"
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.pattern = pattern
+	return rule, true
+}
+
+// globRegexp translates a gitignore glob with *, ?, ** and character classes into a regular expression
+func globRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**"):
+			expr.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
+
+// matchIgnore applies rules in order to rel where the last matching rule decides whether it is ignored
+func matchIgnore(rules []ignoreRule, rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		target := rel
+		if len(rule.base) > 0 {
+			var ok bool
+			if target, ok = strings.CutPrefix(rel, rule.base+"/"); !ok {
+				continue
+			}
+		}
+		if rule.pattern.MatchString(target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// sensitivePlain returns the keys of values that match -mask-keys and are not encrypted
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		values: the new values a write would save
+func sensitivePlain(state *stateful, values map[string]string) []string {
+	patterns := &masker{patterns: state.maskKeys}
+	keys := make([]string, 0)
+	for _, key := range sortedKeys(values) {
+		if patterns.Sensitive(key) && len(strings.TrimSpace(values[key])) > 0 && !isEncrypted(values[key]) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// pickValues returns the values of envs for keys, skipping keys that were removed
+func pickValues(envs map[string]string, keys []string) map[string]string {
+	picked := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if v, ok := envs[key]; ok {
+			picked[key] = v
+		}
+	}
+	return picked
+}
+
+// gitGuard exits before a write that would put plain sensitive values into a file tracked by git unless -force is
+// given, and warns when the file is not ignored by git at all
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		path: the env file that would be written
+// 		values: the new values of the keys the write changes
+func gitGuard(state *stateful, path string, values map[string]string) {
+	keys := sensitivePlain(state, values)
+	if len(keys) == 0 {
+		return
+	}
+	repo, ok := findGitRepo(filepath.Dir(path))
+	if !ok {
+		return
+	}
+	tracked, err := repo.Tracked(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: could not read the git index of %s: %v\n", repo.Root, err)
+		return
+	}
+	if tracked {
+		if !state.force {
+			_, _ = fmt.Fprintf(os.Stderr, "HALT: %s is tracked by git and would hold %s in plain text! WRITE OPERATION "+
+				"CANCELED. Use -%s, untrack the file or use -%s.\n", path, strings.Join(keys, ", "), argEncrypt, argForce)
+			os.Exit(1)
+		}
+		if err = recordForce(state, path); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "HALT: -%s could not be recorded: %v\n", argForce, err)
+			os.Exit(1)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: %s is tracked by git, writing %s anyway because of -%s\n", path, strings.Join(keys, ", "), argForce)
+		return
+	}
+	ignored, err := repo.Ignored(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: could not read the .gitignore rules of %s: %v\n", repo.Root, err)
+		return
+	}
+	if !ignored {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: %s is not ignored by git and holds %s, use -%s to ignore it\n", path, strings.Join(keys, ", "), argGitIgnore)
+	}
+}
+
+// GitIgnore appends the entries that keep env files out of git to the .gitignore at the root of the repository
+// around argEnvFile, leaving .env.example committed
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file services/api/.env -gitignore -write
+func GitIgnore(figs figtree.Plant, state *stateful) {
+	repo, ok := findGitRepo(filepath.Dir(state.Path))
+	if !ok {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %s is not inside a git repository\n", argGitIgnore, state.Path)
+		os.Exit(1)
+	}
+	path := filepath.Join(repo.Root, ".gitignore")
+	contents, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v\n", path, err)
+		os.Exit(1)
+	}
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(contents), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	entries := append([]string{}, gitIgnoreEntries...)
+	if rel, relErr := repo.relative(state.Path); relErr == nil && !matchIgnore(gitIgnoreRules(), rel, false) {
+		entries = append(entries, "/"+rel)
+	}
+	var missing bytes.Buffer
+	for _, entry := range entries {
+		if !existing[entry] {
+			missing.WriteString(entry + "\n")
+		}
+	}
+	if missing.Len() == 0 {
+		if *figs.Bool(argVerbose) {
+			fmt.Printf("%s already ignores env files\n", path)
+		}
+	} else if !state.write {
+		fmt.Print(missing.String())
+		fmt.Printf("The -write flag can be used to append these to %s\n", path)
+	} else {
+		if len(contents) > 0 && contents[len(contents)-1] != '\n' {
+			contents = append(contents, '\n')
+		}
+		if writeErr := saveFileAtomic(state, path, append(contents, missing.Bytes()...)); writeErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s", path, writeErr)
+			os.Exit(1)
+		}
+	}
+	if tracked, _ := repo.Tracked(state.Path); tracked {
+		rel, _ := repo.relative(state.Path)
+		fmt.Printf("%s is still tracked, run: git rm --cached %s\n", state.Path, rel)
+	}
+	finish(state)
+}
+
+// gitIgnoreRules returns gitIgnoreEntries as rules relative to the root of the repository
+func gitIgnoreRules() []ignoreRule {
+	rules := make([]ignoreRule, 0, len(gitIgnoreEntries))
+	for _, entry := range gitIgnoreEntries {
+		if rule, ok := parseIgnoreRule(entry, ""); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
@@ -3,10 +3,13 @@ module github.com/andreimerlescu/goenv
 go 1.24.5
 
 require (
-	github.com/andreimerlescu/checkfs v1.0.4 // indirect
-	github.com/andreimerlescu/figtree/v2 v2.0.14 // indirect
+	github.com/andreimerlescu/checkfs v1.0.4
+	github.com/andreimerlescu/figtree/v2 v2.0.14
+	golang.org/x/term v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/go-ini/ini v1.67.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
-	golang.org/x/term v0.34.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/andreimerlescu/goenv/env"
+)
+
+// includeDirectives are the line prefixes that pull another env file into the one being read
+var includeDirectives = []string{"#include", "source"}
+
+// quotedDirectives are the includeDirectives that only name a file when the path is quoted, so that an ordinary
+// comment such as "#include this in prod" stays a comment
+var quotedDirectives = map[string]bool{"#include": true}
+
+// sourceLine is a single line of an env file after its include directives have been expanded
+type sourceLine struct {
+	File string
+	Line int
+	Text string
+}
+
+// includeTarget returns the path named by an include directive on line relative to the including file, where
+// #include requires the path in quotes such as #include "../shared.env"
+//
+// Parameters:
+// 		line: a trimmed line of an env file
+func includeTarget(line string) (string, bool) {
+	for _, directive := range includeDirectives {
+		rest, found := strings.CutPrefix(line, directive)
+		if !found || len(rest) == 0 || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		quoted := len(rest) >= 2 && strings.ContainsRune(`"'`, rune(rest[0])) && rest[len(rest)-1] == rest[0]
+		if quotedDirectives[directive] && !quoted {
+			return "", false
+		}
+		target := strings.Trim(rest, `"'`)
+		if len(target) == 0 || strings.Contains(target, env.MapItemSeparator) {
+			return "", false
+		}
+		return target, true
+	}
+	return "", false
+}
+
+// readSources expands each file with its include directives in place so that a key defined after an include
+// overrides the included value, and a file that includes itself through any chain is reported as a cycle
+//
+// Parameters:
+// 		files: env files ordered from lowest to highest priority
+func readSources(files []string) ([]sourceLine, error) {
+	lines := make([]sourceLine, 0)
+	for _, path := range files {
+		expanded, err := expandIncludes(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, expanded...)
+	}
+	return lines, nil
+}
+
+// expandIncludes reads path and replaces each include directive with the lines of the file it names
+//
+// Parameters:
+// 		path: the env file to read
+// 		chain: the absolute paths of the files currently being expanded
+func expandIncludes(path string, chain []string) ([]sourceLine, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, seen := range chain {
+		if seen == abs {
+			return nil, fmt.Errorf("include cycle %s", strings.Join(append(chain[i:], abs), " -> "))
+		}
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	chain = append(chain, abs)
+	lines := make([]sourceLine, 0)
+	for i, text := range strings.Split(string(contents), "\n") {
+		if target, ok := includeTarget(strings.TrimSpace(text)); ok {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			included, includeErr := expandIncludes(target, chain)
+			if includeErr != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, i+1, includeErr)
+			}
+			lines = append(lines, included...)
+			continue
+		}
+		lines = append(lines, sourceLine{File: path, Line: i + 1, Text: text})
+	}
+	return lines, nil
+}
+
+// joinSources renders the expanded lines back into the combined contents that Run parses
+func joinSources(lines []sourceLine) []byte {
+	var bb bytes.Buffer
+	for _, line := range lines {
+		bb.WriteString(line.Text)
+		bb.WriteString("\n")
+	}
+	return bb.Bytes()
+}
+
+// includesOthers reports whether any of the expanded lines came from a file other than path
+func includesOthers(lines []sourceLine, path string) bool {
+	for _, line := range lines {
+		if line.File != path {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOwners saves envs by editing the file that owns each key in place: changed values replace the effective
+// definition, removed keys lose every definition and new keys are appended to -file, leaving included files
+// whose keys did not change and the include directives themselves untouched
+//
+// Parameters:
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+func writeOwners(envs map[string]string, state *stateful) error {
+	defined := make(map[string][]sourceLine)
+	for _, line := range state.sources {
+		text := strings.TrimSpace(line.Text)
+		if len(text) < 3 {
+			continue
+		}
+		parts := strings.SplitN(text, env.MapItemSeparator, env.MapSplitN)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		defined[key] = append(defined[key], line)
+	}
+
+	edits := make(map[string]map[int]*string)
+	keys := make(map[string][]string)
+	edit := func(key string, line sourceLine, replacement *string) {
+		if edits[line.File] == nil {
+			edits[line.File] = make(map[int]*string)
+		}
+		edits[line.File][line.Line] = replacement
+		keys[line.File] = append(keys[line.File], key)
+	}
+	for key, lines := range defined {
+		value, kept := envs[key]
+		if !kept {
+			for _, line := range lines {
+				edit(key, line, nil)
+			}
+			continue
+		}
+		last := lines[len(lines)-1]
+		parts := strings.SplitN(strings.TrimSpace(last.Text), env.MapItemSeparator, env.MapSplitN)
+		if unquoteValue(strings.TrimSpace(parts[1])) == strings.TrimSpace(value) {
+			continue
+		}
+		if state.quote {
+			value = quoteValue(value)
+		}
+		replacement := fmt.Sprintf("%s=%s", key, strings.TrimSpace(value))
+		edit(key, last, &replacement)
+	}
+
+	added := make([]string, 0)
+	for _, key := range sortedKeys(envs) {
+		if _, exists := defined[key]; exists {
+			continue
+		}
+		value := strings.TrimSpace(envs[key])
+		if state.quote {
+			value = quoteValue(value)
+		}
+		added = append(added, fmt.Sprintf("%s=%s", strings.TrimSpace(key), value))
+		keys[state.Path] = append(keys[state.Path], key)
+	}
+	if len(added) > 0 && edits[state.Path] == nil {
+		edits[state.Path] = make(map[int]*string)
+	}
+
+	files := make([]string, 0, len(edits))
+	for file := range edits {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		guardWrite(state, file, keys[file])
+		gitGuard(state, file, pickValues(envs, keys[file]))
+	}
+
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		lines := strings.Split(string(contents), "\n")
+		out := make([]string, 0, len(lines)+len(added))
+		for i, line := range lines {
+			replacement, edited := edits[file][i+1]
+			if !edited {
+				out = append(out, line)
+			} else if replacement != nil {
+				out = append(out, *replacement)
+			}
+		}
+		if file == state.Path && len(added) > 0 {
+			if n := len(out); n > 0 && out[n-1] == "" {
+				out = out[:n-1]
+			}
+			out = append(out, added...)
+			out = append(out, "")
+		}
+		if err = saveFileAtomic(state, file, []byte(strings.Join(out, "\n"))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/env"
+)
+
+// journalChange is the value of a key before and after a mutation, where nil means the key was not defined, Sealed
+// means both values are encrypted with the vault and Redacted means only their fingerprint was kept
+type journalChange struct {
+	Key      string  `json:"key"`
+	Old      *string `json:"old,omitempty"`
+	New      *string `json:"new,omitempty"`
+	Sealed   bool    `json:"sealed,omitempty"`
+	Redacted bool    `json:"redacted,omitempty"`
+}
+
+// journalEntry is a single mutation of an env file with enough information to invert it
+type journalEntry struct {
+	Time      time.Time       `json:"time"`
+	User      string          `json:"user"`
+	Operation string          `json:"operation"`
+	Changes   []journalChange `json:"changes"`
+}
+
+// journal is the undo history of a single env file where Cursor counts the entries that are currently applied
+type journal struct {
+	File    string         `json:"file"`
+	Cursor  int            `json:"cursor"`
+	Entries []journalEntry `json:"entries"`
+}
+
+// defaultJournalDir returns ~/.config/goenv/journal next to the user configuration
+func defaultJournalDir() string {
+	return filepath.Join(filepath.Dir(defaultConfigFile()), journalFolder)
+}
+
+// journalPath returns the journal of path inside dir, named after the file and a hash of its absolute path
+func journalPath(dir, path string) (string, string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return filepath.Join(dir, filepath.Base(abs)+"-"+hashValue(abs)[:12]+".json"), abs
+}
+
+// readJournal loads the journal of path from dir, returning an empty journal when none was recorded yet
+func readJournal(dir, path string) (journal, string, error) {
+	file, abs := journalPath(dir, path)
+	j := journal{File: abs, Entries: []journalEntry{}}
+	contents, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return j, file, nil
+	}
+	if err != nil {
+		return j, file, err
+	}
+	if err = json.Unmarshal(contents, &j); err != nil {
+		return j, file, fmt.Errorf("%s is not a journal: %w", file, err)
+	}
+	j.Cursor = min(max(j.Cursor, 0), len(j.Entries))
+	return j, file, nil
+}
+
+// writeJournal saves j to file with 0600 since the journal holds the values it needs to restore
+func writeJournal(file string, j journal) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	contents, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, contents, 0600)
+}
+
+// sealValue encrypts value with v, keeping nil for a key that was not defined
+func sealValue(v *vault, value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	sealed, err := v.Encrypt(*value)
+	return &sealed, err
+}
+
+// openValue decrypts a value that sealValue encrypted, keeping nil for a key that was not defined
+func openValue(state *stateful, value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	plain, err := decryptValue(state, *value)
+	return &plain, err
+}
+
+// fingerprintValue replaces value with its fingerprint, keeping nil for a key that was not defined
+func fingerprintValue(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	fingerprint := maskValue(*value)
+	return &fingerprint
+}
+
+// sealChange keeps plain secrets out of the journal: when the key is sensitive or either value is encrypted, the
+// plain values are encrypted with the vault, or replaced with their fingerprint when no key file or passphrase is
+// available, in which case -undo and -redo can still remove the key but never restore its value
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		change: the values of a key before and after a mutation
+func sealChange(state *stateful, change journalChange) journalChange {
+	secret, plain := (&masker{patterns: state.maskKeys}).Sensitive(change.Key), false
+	for _, value := range []*string{change.Old, change.New} {
+		if value == nil {
+			continue
+		}
+		if isEncrypted(*value) {
+			secret = true
+		} else {
+			plain = true
+		}
+	}
+	if !secret || !plain {
+		return change
+	}
+	if state.vault == nil {
+		if v, err := openVault(state.keyFile, EnvPassphrase, false); err == nil {
+			state.vault = v
+		}
+	}
+	if state.vault != nil {
+		old, oldErr := sealValue(state.vault, change.Old)
+		updated, newErr := sealValue(state.vault, change.New)
+		if oldErr == nil && newErr == nil {
+			change.Old, change.New, change.Sealed = old, updated, true
+			return change
+		}
+	}
+	change.Old, change.New, change.Redacted = fingerprintValue(change.Old), fingerprintValue(change.New), true
+	return change
+}
+
+// unsealEntry returns entry with its sealed changes decrypted, leaving the fingerprints of redacted changes as-is
+func unsealEntry(state *stateful, entry journalEntry) (journalEntry, error) {
+	opened := entry
+	opened.Changes = make([]journalChange, 0, len(entry.Changes))
+	for _, change := range entry.Changes {
+		if change.Sealed {
+			old, oldErr := openValue(state, change.Old)
+			updated, newErr := openValue(state, change.New)
+			if err := errors.Join(oldErr, newErr); err != nil {
+				return entry, fmt.Errorf("cannot decrypt the journal of %s: %w", change.Key, err)
+			}
+			change.Old, change.New, change.Sealed = old, updated, false
+		}
+		opened.Changes = append(opened.Changes, change)
+	}
+	return opened, nil
+}
+
+// recordJournal appends the changes between before and after to the journal of path, discarding the entries that
+// were undone and keeping the last journalLimit entries, warning on stderr when the journal cannot be written, and
+// sealing the values of secrets with sealChange
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		operation: the flag that caused the mutation such as add, rm, write or mv
+// 		path: the file that was mutated
+// 		before: the envs of path before the mutation
+// 		after: the envs of path after the mutation
+func recordJournal(state *stateful, operation, path string, before, after map[string]string) {
+	keys := changedKeys(before, after)
+	if len(keys) == 0 || len(state.journalDir) == 0 {
+		return
+	}
+	entry := journalEntry{Time: time.Now().UTC(), User: env.User().Username, Operation: operation}
+	for _, key := range keys {
+		change := journalChange{Key: key}
+		if v, ok := before[key]; ok {
+			change.Old = &v
+		}
+		if v, ok := after[key]; ok {
+			change.New = &v
+		}
+		entry.Changes = append(entry.Changes, sealChange(state, change))
+	}
+	j, file, err := readJournal(state.journalDir, path)
+	if err == nil {
+		j.Entries = append(j.Entries[:j.Cursor], entry)
+		if len(j.Entries) > journalLimit {
+			j.Entries = j.Entries[len(j.Entries)-journalLimit:]
+		}
+		j.Cursor = len(j.Entries)
+		err = writeJournal(file, j)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: could not write the journal of %s: %v\n", path, err)
+	}
+}
+
+// sameValue reports whether the current definition of a key matches the value a journalChange expects, comparing
+// fingerprints when the change was redacted
+func sameValue(current string, defined bool, expected *string, redacted bool) bool {
+	if expected == nil {
+		return !defined
+	}
+	if redacted {
+		return defined && maskValue(strings.TrimSpace(current)) == *expected
+	}
+	return defined && strings.TrimSpace(current) == strings.TrimSpace(*expected)
+}
+
+// replay applies entry to envs, forwards for -redo or backwards for -undo, skipping every key that was edited since
+// so that unrelated later edits survive, and returns the updated envs with the keys it applied, the keys it skipped
+// and the redacted keys whose value it cannot restore
+func replay(envs map[string]string, entry journalEntry, forward bool) (map[string]string, []string, []string, []string) {
+	updated := make(map[string]string, len(envs))
+	for k, v := range envs {
+		updated[k] = v
+	}
+	applied, skipped, withheld := make([]string, 0), make([]string, 0), make([]string, 0)
+	for _, change := range entry.Changes {
+		from, to := change.New, change.Old
+		if forward {
+			from, to = change.Old, change.New
+		}
+		current, defined := envs[change.Key]
+		if !sameValue(current, defined, from, change.Redacted) {
+			skipped = append(skipped, change.Key)
+			continue
+		}
+		if to != nil && change.Redacted {
+			withheld = append(withheld, change.Key)
+			continue
+		}
+		if to == nil {
+			delete(updated, change.Key)
+		} else {
+			updated[change.Key] = *to
+		}
+		applied = append(applied, change.Key)
+	}
+	return updated, applied, skipped, withheld
+}
+
+// Undo reverts the last applied entry of the journal of argEnvFile, or reapplies the last undone entry with -redo,
+// leaving keys that were edited since the entry alone
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env -undo -write
+// 		goenv -file .env -redo -write
+func Undo(figs figtree.Plant, envs map[string]string, state *stateful) {
+	name, forward := argUndo, state.redo
+	if forward {
+		name = argRedo
+	}
+	j, file, err := readJournal(state.journalDir, state.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", name, err)
+		os.Exit(1)
+	}
+	index := j.Cursor - 1
+	if forward {
+		index = j.Cursor
+	}
+	if index < 0 || index >= len(j.Entries) {
+		_, _ = fmt.Fprintf(os.Stderr, "nothing to %s in %s\n", name, state.Path)
+		os.Exit(1)
+	}
+	entry, err := unsealEntry(state, j.Entries[index])
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", name, err)
+		os.Exit(1)
+	}
+	updated, applied, skipped, withheld := replay(envs, entry, forward)
+	for _, key := range withheld {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: %s was journaled without its value since no key was available, leaving it alone\n", key)
+	}
+	for _, key := range skipped {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: %s changed since %s at %s, leaving it alone\n", key, entry.Operation,
+			entry.Time.Local().Format(time.DateTime))
+	}
+	if !state.write {
+		for _, key := range applied {
+			fmt.Printf("%s %s\n", name, key)
+		}
+		fmt.Printf("The -write flag can be used to %s %s of %s\n", name, entry.Operation, state.Path)
+		os.Exit(0)
+	}
+	if len(applied) > 0 {
+		if err = writeOwners(updated, state); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s\n", state.Path, err)
+			os.Exit(1)
+		}
+		auditChange(state, name, state.Path, envs, updated)
+	}
+	j.Cursor = index
+	if forward {
+		j.Cursor = index + 1
+	}
+	if state.dryRun {
+		finish(state)
+	}
+	if err = writeJournal(file, j); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", name, err)
+		os.Exit(1)
+	}
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("%s %s of %s: %d keys applied, %d skipped\n", name, entry.Operation, state.Path, len(applied), len(skipped))
+	}
+	os.Exit(0)
+}
+
+// History prints the journal of argEnvFile with the keys each entry changed, marking the entries that were undone
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env -history
+// 		goenv -file .env -history -json
+func History(figs figtree.Plant, state *stateful) {
+	if len(state.Path) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s requires -%s\n", argHistory, argEnvFile)
+		os.Exit(1)
+	}
+	j, _, err := readJournal(state.journalDir, state.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argHistory, err)
+		os.Exit(1)
+	}
+	if len(state.formats) > 0 {
+		// values stay in the journal, the history only shows which keys changed
+		for i := range j.Entries {
+			for c := range j.Entries[i].Changes {
+				j.Entries[i].Changes[c].Old, j.Entries[i].Changes[c].New = nil, nil
+			}
+		}
+		output, jsonErr := json.MarshalIndent(j, "", "  ")
+		if jsonErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argHistory, jsonErr)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		os.Exit(0)
+	}
+	for i, entry := range j.Entries {
+		keys := make([]string, 0, len(entry.Changes))
+		for _, change := range entry.Changes {
+			keys = append(keys, change.Key)
+		}
+		undone := ""
+		if i >= j.Cursor {
+			undone = " (undone)"
+		}
+		fmt.Printf("%d %s %s %s %s%s\n", i+1, entry.Time.Local().Format(time.DateTime), entry.User, entry.Operation,
+			strings.Join(keys, ","), undone)
+	}
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("%d entries, %d applied in %s\n", len(j.Entries), j.Cursor, state.Path)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// masker redacts the values of sensitive keys in printed and exported output
+type masker struct {
+	patterns []string
+	reveal   []string
+	style    string
+}
+
+// newMasker returns the masker of state, or nil when -mask is not enabled so that every value is shown
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+func newMasker(state *stateful) (*masker, error) {
+	if !state.mask {
+		return nil, nil
+	}
+	if state.maskStyle != maskHash && state.maskStyle != maskLength {
+		return nil, fmt.Errorf("-%s must be %s or %s", argMaskStyle, maskHash, maskLength)
+	}
+	return &masker{patterns: state.maskKeys, reveal: state.reveal, style: state.maskStyle}, nil
+}
+
+// Sensitive reports whether key matches a -mask-keys glob and is not listed in -reveal, compared case-insensitively
+func (m *masker) Sensitive(key string) bool {
+	if m == nil {
+		return false
+	}
+	for _, revealed := range m.reveal {
+		if strings.EqualFold(strings.TrimSpace(revealed), key) {
+			return false
+		}
+	}
+	for _, pattern := range m.patterns {
+		pattern = strings.TrimSpace(pattern)
+		if ok, _ := filepath.Match(strings.ToUpper(pattern), strings.ToUpper(key)); ok && len(pattern) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns value, or its redacted form when key is Sensitive
+func (m *masker) Value(key, value string) string {
+	if !m.Sensitive(key) {
+		return value
+	}
+	if m.style == maskLength {
+		return fmt.Sprintf("redacted:%d", len(value))
+	}
+	return maskValue(value)
+}
+
+// Envs returns a copy of envs with the values of Sensitive keys redacted, or envs itself when nothing is masked
+func (m *masker) Envs(envs map[string]string) map[string]string {
+	if m == nil {
+		return envs
+	}
+	masked := make(map[string]string, len(envs))
+	for k, v := range envs {
+		masked[k] = m.Value(k, v)
+	}
+	return masked
+}
+
+// maskValue replaces value with a short SHA-256 so equal values can still be compared without revealing them
+func maskValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])[:8]
+}
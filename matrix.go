@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+)
+
+const (
+	matrixText     string = "text"
+	matrixCsv      string = "csv"
+	matrixJson     string = "json"
+	matrixMarkdown string = "markdown"
+
+	cellPresence string = "presence"
+	cellHash     string = "hash"
+	cellValue    string = "value"
+
+	cellPresent string = "present"
+	cellMissing string = "missing"
+)
+
+type (
+	// matrixRow is a key of -matrix with a cell for each file
+	matrixRow struct {
+		Key      string             `json:"key" yaml:"key" toml:"key" xml:"key" ini:"key"`
+		Diverges bool               `json:"diverges" yaml:"diverges" toml:"diverges" xml:"diverges" ini:"diverges"`
+		Cells    map[string]*string `json:"cells" yaml:"cells" toml:"cells" xml:"cells" ini:"cells"`
+	}
+
+	// matrixReport is the rendered result of -matrix
+	matrixReport struct {
+		Files []string    `json:"files" yaml:"files" toml:"files" xml:"files" ini:"files"`
+		Rows  []matrixRow `json:"rows" yaml:"rows" toml:"rows" xml:"rows" ini:"rows"`
+	}
+)
+
+// expandFiles resolves each pattern with filepath.Glob keeping patterns without matches as literal paths
+func expandFiles(patterns []string) ([]string, error) {
+	files := make([]string, 0, len(patterns))
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if len(pattern) == 0 {
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			matches = []string{pattern}
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+// buildMatrix reads every file and renders a row per key with cells according to cell
+//
+// Parameters:
+// 		files: env files that make up the columns
+// 		cell: one of cellPresence, cellHash or cellValue
+// 		mask: redacts the values of sensitive keys when cell is cellValue
+func buildMatrix(files []string, cell string, mask *masker) (matrixReport, error) {
+	report := matrixReport{Files: files, Rows: []matrixRow{}}
+	loaded := make(map[string]map[string]string, len(files))
+	union := make(map[string]string)
+	for _, file := range files {
+		envs, err := readEnvFile(file)
+		if err != nil {
+			return report, err
+		}
+		loaded[file] = envs
+		for k := range envs {
+			union[k] = ""
+		}
+	}
+	for _, k := range sortedKeys(union) {
+		row := matrixRow{Key: k, Cells: make(map[string]*string, len(files))}
+		distinct := make(map[string]bool)
+		for _, file := range files {
+			v, ok := loaded[file][k]
+			if !ok {
+				row.Cells[file] = nil
+				continue
+			}
+			distinct[v] = true
+			var rendered string
+			switch {
+			case cell == cellHash:
+				rendered = maskValue(v)
+			case cell == cellValue:
+				rendered = mask.Value(k, v)
+			default:
+				rendered = cellPresent
+			}
+			row.Cells[file] = &rendered
+		}
+		row.Diverges = len(distinct) > 1
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+// records flattens the report into a header and rows of strings, marking diverging keys when marker is not empty
+func (report matrixReport) records(marker string) [][]string {
+	records := [][]string{append([]string{"KEY"}, report.Files...)}
+	for _, row := range report.Rows {
+		key := row.Key
+		if row.Diverges && len(marker) > 0 {
+			key = marker + key
+		}
+		record := []string{key}
+		for _, file := range report.Files {
+			if c := row.Cells[file]; c != nil {
+				record = append(record, *c)
+			} else {
+				record = append(record, cellMissing)
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Matrix prints a table of every key across the -matrix files, marking keys whose values diverge with a *
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -matrix '.env*'
+// 		goenv -matrix .env.staging,.env.production -cell hash -matrix-format markdown
+func Matrix(figs figtree.Plant, state *stateful) {
+	files, err := expandFiles(state.matrix)
+	if err != nil || len(files) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s requires at least one file: %v\n", argMatrix, err)
+		os.Exit(1)
+	}
+	switch state.cell {
+	case cellPresence, cellHash, cellValue:
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "-%s must be one of %s %s %s\n", argCell, cellPresence, cellHash, cellValue)
+		os.Exit(1)
+	}
+	format := state.matrixFormat
+	if len(state.formats) > 0 && state.formats[0].Name() == argJson {
+		format = matrixJson
+	}
+	report, err := buildMatrix(files, state.cell, state.masker)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argMatrix, err)
+		os.Exit(1)
+	}
+
+	switch format {
+	case matrixJson:
+		output, jsonErr := json.MarshalIndent(report, "", "  ")
+		if jsonErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argMatrix, jsonErr)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+	case matrixCsv:
+		w := csv.NewWriter(os.Stdout)
+		records := report.records("")
+		records[0] = append(records[0], "DIVERGES")
+		for i, row := range report.Rows {
+			records[i+1] = append(records[i+1], fmt.Sprintf("%v", row.Diverges))
+		}
+		if csvErr := w.WriteAll(records); csvErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error writing -%s: %v\n", argMatrix, csvErr)
+			os.Exit(1)
+		}
+	case matrixMarkdown:
+		records := report.records("")
+		for i, record := range records {
+			if i > 0 && report.Rows[i-1].Diverges {
+				record[0] = "**" + record[0] + "**"
+			}
+			fmt.Printf("| %s |\n", strings.Join(record, " | "))
+			if i == 0 {
+				fmt.Printf("|%s\n", strings.Repeat(" --- |", len(record)))
+			}
+		}
+	case matrixText:
+		records := report.records("* ")
+		widths := make([]int, len(records[0]))
+		for _, record := range records {
+			for i, c := range record {
+				widths[i] = max(widths[i], len(c))
+			}
+		}
+		for _, record := range records {
+			line := make([]string, len(record))
+			for i, c := range record {
+				line[i] = c + strings.Repeat(" ", widths[i]-len(c))
+			}
+			fmt.Println(strings.TrimRight(strings.Join(line, "  "), " "))
+		}
+		if *figs.Bool(argVerbose) {
+			fmt.Println("* values diverge between files")
+		}
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "-%s must be one of %s %s %s %s\n", argMatrixFormat, matrixText, matrixCsv, matrixJson, matrixMarkdown)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+)
+
+const (
+	mergeLast        string = "last"
+	mergeFirst       string = "first"
+	mergeFail        string = "fail"
+	mergeInteractive string = "interactive"
+)
+
+// override records a key whose value from one -merge file was replaced by another
+type override struct {
+	Key             string
+	Kept, Discarded string
+}
+
+// mergeEnvs combines files in order resolving keys with different values using strategy
+//
+// Parameters:
+// 		files: env files to merge in order
+// 		strategy: one of mergeLast, mergeFirst, mergeFail or mergeInteractive
+// 		in: where mergeInteractive reads answers from
+// 		out: where mergeInteractive writes prompts to
+func mergeEnvs(files []string, strategy string, in io.Reader, out io.Writer) (map[string]string, []override, error) {
+	merged := make(map[string]string)
+	owner := make(map[string]string)
+	overrides := make([]override, 0)
+	reader := bufio.NewReader(in)
+	for _, file := range files {
+		envs, err := readEnvFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, k := range sortedKeys(envs) {
+			v := envs[k]
+			existing, ok := merged[k]
+			if !ok {
+				merged[k], owner[k] = v, file
+				continue
+			}
+			if existing == v {
+				continue
+			}
+			o := override{Key: k}
+			switch strategy {
+			case mergeFirst:
+				o.Kept, o.Discarded = owner[k], file
+			case mergeFail:
+				return nil, nil, fmt.Errorf("%s conflicts between %s and %s", k, owner[k], file)
+			case mergeInteractive:
+				_, _ = fmt.Fprintf(out, "%s\n  [1] %s: %s=%s\n  [2] %s: %s=%s\nKeep which? [2] ", k, owner[k], k, existing, file, k, v)
+				answer, readErr := reader.ReadString('\n')
+				if readErr != nil && len(answer) == 0 {
+					return nil, nil, fmt.Errorf("no answer for %s: %w", k, readErr)
+				}
+				if choice, _ := strconv.Atoi(strings.TrimSpace(answer)); choice == 1 {
+					o.Kept, o.Discarded = owner[k], file
+					break
+				}
+				fallthrough
+			default:
+				o.Kept, o.Discarded = file, owner[k]
+				merged[k], owner[k] = v, file
+			}
+			overrides = append(overrides, o)
+		}
+	}
+	return merged, overrides, nil
+}
+
+// Merge combines the -merge files using -strategy, reports every overridden key to STDERR and hands the result to
+// Result so it can be printed, exported or written to argEnvFile with -write
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env -merge base.env,team.env -strategy first -write
+// 		goenv -merge base.env,team.env -json
+func Merge(figs figtree.Plant, state *stateful) {
+	switch state.strategy {
+	case mergeLast, mergeFirst, mergeFail, mergeInteractive:
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "-%s must be one of %s %s %s %s\n", argStrategy, mergeLast, mergeFirst, mergeFail, mergeInteractive)
+		os.Exit(1)
+	}
+	merged, overrides, err := mergeEnvs(state.merge, state.strategy, os.Stdin, os.Stderr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argMerge, err)
+		os.Exit(1)
+	}
+	for _, o := range overrides {
+		_, _ = fmt.Fprintf(os.Stderr, "overridden %s: kept %s, discarded %s\n", o.Key, o.Kept, o.Discarded)
+	}
+	if *figs.Bool(argVerbose) {
+		_, _ = fmt.Fprintf(os.Stderr, "merged %d keys from %s with %d overrides\n", len(merged), strings.Join(state.merge, ", "), len(overrides))
+	}
+	if !state.write && !state.mkAll && len(state.formats) == 0 && len(state.template) == 0 {
+		state.printer = true
+	}
+	Result(figs, merged, state)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+)
+
+// permFinding is the permission check of a single source file of argEnvFile
+type permFinding struct {
+	File      string   `json:"file"`
+	Mode      string   `json:"mode"`
+	Owner     int      `json:"owner"`
+	Sensitive []string `json:"sensitive"`
+	Issues    []string `json:"issues"`
+}
+
+// checkPerms inspects the mode and owner of every file that makes up argEnvFile, flagging group or world readable
+// files and files owned by another user when they hold plain values of sensitive keys
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+func checkPerms(state *stateful) ([]permFinding, error) {
+	lines := make(map[string][]sourceLine)
+	order := make([]string, 0)
+	for _, line := range state.sources {
+		if _, seen := lines[line.File]; !seen {
+			order = append(order, line.File)
+		}
+		lines[line.File] = append(lines[line.File], line)
+	}
+	findings := make([]permFinding, 0, len(order))
+	for _, file := range order {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		finding := permFinding{
+			File:      file,
+			Mode:      fmt.Sprintf("%04o", info.Mode().Perm()),
+			Owner:     -1,
+			Sensitive: sensitivePlain(state, parseEnvs(joinSources(lines[file]))),
+			Issues:    []string{},
+		}
+		if uid, ok := fileOwner(info); ok {
+			finding.Owner = uid
+		}
+		if len(finding.Sensitive) > 0 {
+			if info.Mode().Perm()&0o077 != 0 {
+				finding.Issues = append(finding.Issues, fmt.Sprintf("mode %s is accessible by group or others", finding.Mode))
+			}
+			if finding.Owner >= 0 && finding.Owner != os.Geteuid() {
+				finding.Issues = append(finding.Issues, fmt.Sprintf("owned by uid %d instead of %d", finding.Owner, os.Geteuid()))
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// enforcePerms warns on stderr about every permission issue of argEnvFile, or exits when -perms is permsFail
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+func enforcePerms(state *stateful) {
+	if state.perms == permsOff || len(state.Info.Name) == 0 {
+		return
+	}
+	findings, err := checkPerms(state)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: could not check permissions: %v\n", err)
+		return
+	}
+	failed := false
+	for _, finding := range findings {
+		for _, issue := range finding.Issues {
+			failed = true
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: %s holds %s but %s, use -%s\n", finding.File,
+				strings.Join(finding.Sensitive, ", "), issue, argFixPerms)
+		}
+	}
+	if failed && state.perms == permsFail {
+		_, _ = fmt.Fprintf(os.Stderr, "HALT: -%s %s\n", argPerms, permsFail)
+		os.Exit(1)
+	}
+}
+
+// CheckPerms prints the permission check of every file that makes up argEnvFile and exits 1 when any has an issue
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env.production -check-perms
+// 		goenv -recursive services -check-perms -report json
+func CheckPerms(figs figtree.Plant, state *stateful) {
+	findings, err := checkPerms(state)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argCheckPerms, err)
+		os.Exit(1)
+	}
+	code := 0
+	for _, finding := range findings {
+		if len(finding.Issues) > 0 {
+			code = 1
+		}
+	}
+	if len(state.formats) > 0 {
+		output, jsonErr := json.MarshalIndent(findings, "", "  ")
+		if jsonErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argCheckPerms, jsonErr)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		os.Exit(code)
+	}
+	for _, finding := range findings {
+		status := "ok"
+		if len(finding.Issues) > 0 {
+			status = strings.Join(finding.Issues, "; ")
+		}
+		fmt.Printf("%s %s uid=%d sensitive=%d %s\n", finding.Mode, finding.File, finding.Owner, len(finding.Sensitive), status)
+	}
+	os.Exit(code)
+}
+
+// FixPerms changes the mode of every file that makes up argEnvFile and is accessible by group or others to 0600
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env.production -fix-perms
+// 		goenv -recursive services -fix-perms
+func FixPerms(figs figtree.Plant, state *stateful) {
+	findings, err := checkPerms(state)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argFixPerms, err)
+		os.Exit(1)
+	}
+	code := 0
+	for _, finding := range findings {
+		info, statErr := os.Stat(finding.File)
+		if statErr != nil || info.Mode().Perm()&0o077 == 0 {
+			continue
+		}
+		if state.dryRun {
+			fmt.Printf("%s %s -> 0600\n", finding.File, finding.Mode)
+			state.pending = true
+			continue
+		}
+		if chmodErr := os.Chmod(finding.File, 0o600); chmodErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s failed on %s with: %v\n", argFixPerms, finding.File, chmodErr)
+			code = 1
+			continue
+		}
+		auditChange(state, argFixPerms, finding.File, nil, nil)
+		fmt.Printf("%s %s -> 0600\n", finding.File, finding.Mode)
+	}
+	for _, finding := range findings {
+		for _, issue := range finding.Issues {
+			if strings.HasPrefix(issue, "owned by") {
+				_, _ = fmt.Fprintf(os.Stderr, "%s is %s, change its owner with chown\n", finding.File, issue)
+				code = 1
+			}
+		}
+	}
+	if code == 0 {
+		finish(state)
+	}
+	os.Exit(code)
+}
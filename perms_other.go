@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// fileOwner is not available outside unix where files do not carry a uid
+func fileOwner(info os.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -2,123 +2,96 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"text/template"
 
 	"github.com/andreimerlescu/figtree/v2"
 )
 
-// processJson renders the argEnvFile with an ext of outFormatJson
-//
-// Parameters:
-//   	figs: The CLI state of arguments and inputs to the runtime
-// 	 	state: Read-Only verification on export options being singular in choice
-// 		envs: map of environment variables as key=value pairs
-func processJson(figs figtree.Plant, envs map[string]string, state *stateful) {
-	output, err := json.MarshalIndent(envs, "", "  ")
-	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, "Error marshalling environment variable:", state.env)
-		os.Exit(1)
-	}
-	var bb bytes.Buffer
-	bb.Write(output)
-	writeProcessed(figs, &bb, outFormatJson, state)
+// outputName holds the fields available to the -outname template
+type outputName struct {
+	Name   string // base name of the -file, e.g. sample.env
+	Stem   string // base name of the -file without its extension, e.g. sample
+	Ext    string // extension of the export, e.g. .json
+	Format string // name of the export, e.g. json
 }
 
-// processIni renders the argEnvFile with an ext of outFormatIni
+// outputPath resolves where an export with ext is written
 //
 // Parameters:
-//   	figs: The CLI state of arguments and inputs to the runtime
 // 	 	state: Read-Only verification on export options being singular in choice
-// 		envs: map of environment variables as key=value pairs
-func processIni(figs figtree.Plant, envs map[string]string, state *stateful) {
-	var ini bytes.Buffer
-	ini.WriteString("[default]\n")
-	for e, v := range envs {
-		e, v = strings.TrimSpace(e), strings.TrimSpace(v)
-		ini.WriteString(fmt.Sprintf("%s = %s\n", e, v))
+// 		ext: extension of the export format such as outFormatJson
+func outputPath(state *stateful, ext string) (string, error) {
+	if len(state.out) > 0 && !state.mkAll {
+		return state.out, nil
 	}
-	writeProcessed(figs, &ini, outFormatIni, state)
-}
-
-// processToml renders the argEnvFile with an ext of outFormatToml
-//
-// Parameters:
-//   	figs: The CLI state of arguments and inputs to the runtime
-// 	 	state: Read-Only verification on export options being singular in choice
-// 		envs: map of environment variables as key=value pairs
-func processToml(figs figtree.Plant, envs map[string]string, state *stateful) {
-	var toml bytes.Buffer
-	for e, v := range envs {
-		e, v = strings.TrimSpace(e), strings.TrimSpace(v)
-		toml.WriteString(fmt.Sprintf("%s: \"%s\" \n", e, v))
+	name := state.outName
+	if len(name) == 0 {
+		name = outNameDefault
 	}
-	writeProcessed(figs, &toml, outFormatToml, state)
-}
-
-// processYaml renders the argEnvFile with an ext of outFormatYaml
-//
-// Parameters:
-//   	figs: The CLI state of arguments and inputs to the runtime
-// 	 	state: Read-Only verification on export options being singular in choice
-// 		envs: map of environment variables as key=value pairs
-func processYaml(figs figtree.Plant, envs map[string]string, state *stateful) {
-	var yaml bytes.Buffer
-	yaml.WriteString("---\n")
-	for e, v := range envs {
-		e, v = strings.TrimSpace(e), strings.TrimSpace(v)
-		yaml.WriteString(fmt.Sprintf("%s: \"%s\" \n", e, v))
+	tmpl, err := template.New(argOutName).Option("missingkey=error").Parse(name)
+	if err != nil {
+		return "", err
 	}
-	writeProcessed(figs, &yaml, outFormatYaml, state)
-}
-
-// processXml renders the argEnvFile with an ext of outFormatXml
-//
-// Parameters:
-//   	figs: The CLI state of arguments and inputs to the runtime
-// 	 	state: Read-Only verification on export options being singular in choice
-// 		envs: map of environment variables as key=value pairs
-func processXml(figs figtree.Plant, envs map[string]string, state *stateful) {
-	var xml bytes.Buffer
-	xml.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-	xml.WriteString("<env>\n")
-	for e, v := range envs {
-		i := "   "
-		e, v = strings.TrimSpace(e), strings.TrimSpace(v)
-
-		xml.WriteString(i + "<")
-		xml.WriteString(e)
-		xml.WriteString(">")
-		xml.WriteString(v)
-		xml.WriteString("</")
-		xml.WriteString(e)
-		xml.WriteString(">\n")
+	base := filepath.Base(state.Path)
+	var bb bytes.Buffer
+	err = tmpl.Execute(&bb, outputName{
+		Name:   base,
+		Stem:   strings.TrimSuffix(base, filepath.Ext(base)),
+		Ext:    ext,
+		Format: strings.TrimPrefix(ext, "."),
+	})
+	if err != nil {
+		return "", err
+	}
+	dir := state.outDir
+	if len(dir) == 0 {
+		dir = filepath.Dir(state.Path)
 	}
-	xml.WriteString("</env>\n")
-	writeProcessed(figs, &xml, outFormatXml, state)
+	return filepath.Join(dir, bb.String()), nil
 }
 
-// writeProcessed renders the argEnvFile + extension with the buffered bytes
+// writeProcessed renders the export of argEnvFile with the buffered bytes to its outputPath
 //
 // Parameters:
 //   	figs: The CLI state of arguments and inputs to the runtime
+// 		buf: rendered bytes of the export
+// 		ext: extension of the export format such as outFormatJson
 // 	 	state: Read-Only verification on export options being singular in choice
-// 		envs: map of environment variables as key=value pairs
-func writeProcessed(figs figtree.Plant, buf *bytes.Buffer, ext string, state *stateful) {
+func writeProcessed(figs figtree.Plant, buf []byte, ext string, state *stateful) {
+	path, err := outputPath(state, ext)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error rendering -%s %q: %v\n", argOutName, state.outName, err)
+		os.Exit(1)
+	}
 	if state.write {
-		path := fmt.Sprintf("%s%s", state.Path, ext)
-		if writeErr := os.WriteFile(path, buf.Bytes(), 0644); writeErr != nil {
+		if len(state.outDir) > 0 && state.mkAll && !state.dryRun {
+			if mkErr := os.MkdirAll(filepath.Dir(path), 0755); mkErr != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error creating directory %s: %s", filepath.Dir(path), mkErr)
+				os.Exit(1)
+			}
+		}
+		if writeErr := saveFile(state, path, buf, 0600); writeErr != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s", path, writeErr)
 			os.Exit(1)
 		}
-		if *figs.Bool(argVerbose) {
+		if *figs.Bool(argVerbose) && !state.dryRun {
 			fmt.Printf("Writing file %s\n", path)
 		}
+	} else if len(state.out) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "The -write flag can be used to write %s\n", path)
+	}
+	if !state.mkAll && state.dryRun {
+		finish(state)
 	}
 	if !state.mkAll {
-		fmt.Println(buf.String())
+		fmt.Println(string(buf))
 		os.Exit(0)
 	}
+	if !state.write {
+		fmt.Printf("==> %s <==\n%s\n\n", path, strings.TrimRight(string(buf), "\n"))
+	}
 }
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/format"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// profile is a named set of defaults in the projectFile
+	profile struct {
+		Files       []string `json:"files,omitempty" yaml:"files,omitempty" toml:"files" xml:"files" ini:"files"`
+		Cascade     bool     `json:"cascade,omitempty" yaml:"cascade,omitempty" toml:"cascade" xml:"cascade" ini:"cascade"`
+		Stage       string   `json:"stage,omitempty" yaml:"stage,omitempty" toml:"stage" xml:"stage" ini:"stage"`
+		Protect     []string `json:"protect,omitempty" yaml:"protect,omitempty" toml:"protect" xml:"protect" ini:"protect"`
+		ProtectKeys []string `json:"protect_keys,omitempty" yaml:"protect_keys,omitempty" toml:"protect_keys" xml:"protect_keys" ini:"protect_keys"`
+		Mask        bool     `json:"mask,omitempty" yaml:"mask,omitempty" toml:"mask" xml:"mask" ini:"mask"`
+		MaskKeys    []string `json:"mask_keys,omitempty" yaml:"mask_keys,omitempty" toml:"mask_keys" xml:"mask_keys" ini:"mask_keys"`
+		Format      string   `json:"format,omitempty" yaml:"format,omitempty" toml:"format" xml:"format" ini:"format"`
+	}
+
+	// projectConfig is the contents of a projectFile where the top level profile applies to every named profile
+	projectConfig struct {
+		profile  `yaml:",inline"`
+		Profile  string             `json:"profile,omitempty" yaml:"profile,omitempty" toml:"profile" xml:"profile" ini:"profile"`
+		Profiles map[string]profile `json:"profiles,omitempty" yaml:"profiles,omitempty" toml:"profiles" xml:"profiles" ini:"profiles"`
+	}
+
+	// effectiveConfig is the rendered result of -config show
+	effectiveConfig struct {
+		UserConfig    string   `json:"user_config" yaml:"user_config" toml:"user_config" xml:"user_config" ini:"user_config"`
+		ProjectConfig string   `json:"project_config" yaml:"project_config" toml:"project_config" xml:"project_config" ini:"project_config"`
+		Profile       string   `json:"profile" yaml:"profile" toml:"profile" xml:"profile" ini:"profile"`
+		File          string   `json:"file" yaml:"file" toml:"file" xml:"file" ini:"file"`
+		Layers        []string `json:"layers" yaml:"layers" toml:"layers" xml:"layers" ini:"layers"`
+		Cascade       bool     `json:"cascade" yaml:"cascade" toml:"cascade" xml:"cascade" ini:"cascade"`
+		Stage         string   `json:"stage" yaml:"stage" toml:"stage" xml:"stage" ini:"stage"`
+		Protect       []string `json:"protect" yaml:"protect" toml:"protect" xml:"protect" ini:"protect"`
+		ProtectKeys   []string `json:"protect_keys" yaml:"protect_keys" toml:"protect_keys" xml:"protect_keys" ini:"protect_keys"`
+		Mask          bool     `json:"mask" yaml:"mask" toml:"mask" xml:"mask" ini:"mask"`
+		MaskKeys      []string `json:"mask_keys" yaml:"mask_keys" toml:"mask_keys" xml:"mask_keys" ini:"mask_keys"`
+		Formats       []string `json:"formats" yaml:"formats" toml:"formats" xml:"formats" ini:"formats"`
+		DefaultFormat string   `json:"default_format" yaml:"default_format" toml:"default_format" xml:"default_format" ini:"default_format"`
+	}
+)
+
+// findProjectConfig walks up from dir until it finds a projectFile, stopping at the root of the git repository
+//
+// Parameters:
+// 		dir: the directory to start from, usually the working directory
+func findProjectConfig(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		candidate := filepath.Join(dir, projectFile)
+		if info, statErr := os.Stat(candidate); statErr == nil && !info.IsDir() {
+			return candidate, true
+		}
+		if _, statErr := os.Stat(filepath.Join(dir, ".git")); statErr == nil {
+			return "", false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig parses the projectFile at path
+func loadProjectConfig(path string) (*projectConfig, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &projectConfig{}
+	if err = yaml.Unmarshal(contents, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// flagGiven reports whether -name was passed on the command line so a profile never overrides it
+func flagGiven(name string) bool {
+	for _, arg := range os.Args[1:] {
+		arg = strings.TrimLeft(arg, "-")
+		arg, _, _ = strings.Cut(arg, "=")
+		if strings.EqualFold(arg, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveProfile combines the top level defaults of config with the named profile
+func resolveProfile(config *projectConfig, name string) (profile, error) {
+	resolved := config.profile
+	if len(name) == 0 {
+		return resolved, nil
+	}
+	named, ok := config.Profiles[name]
+	if !ok {
+		return resolved, fmt.Errorf("profile %q is not defined", name)
+	}
+	if len(named.Files) > 0 {
+		resolved.Files = named.Files
+	}
+	if named.Cascade {
+		resolved.Cascade = true
+	}
+	if len(named.Stage) > 0 {
+		resolved.Stage = named.Stage
+	}
+	resolved.Protect = append(resolved.Protect, named.Protect...)
+	resolved.ProtectKeys = append(resolved.ProtectKeys, named.ProtectKeys...)
+	if named.Mask {
+		resolved.Mask = true
+	}
+	resolved.MaskKeys = append(resolved.MaskKeys, named.MaskKeys...)
+	if len(named.Format) > 0 {
+		resolved.Format = named.Format
+	}
+	return resolved, nil
+}
+
+// applyProject discovers the projectFile and applies the -profile it selects to state, leaving every flag that was
+// passed on the command line as-is
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+func applyProject(figs figtree.Plant, state *stateful) error {
+	path, found := findProjectConfig(".")
+	if !found {
+		if len(state.profile) > 0 {
+			return fmt.Errorf("-%s %s requires a %s", argProfile, state.profile, projectFile)
+		}
+		return nil
+	}
+	config, err := loadProjectConfig(path)
+	if err != nil {
+		return err
+	}
+	state.project = path
+	if len(state.profile) == 0 {
+		state.profile = config.Profile
+	}
+	resolved, err := resolveProfile(config, state.profile)
+	if err != nil {
+		return err
+	}
+	root := filepath.Dir(path)
+	relative := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(root, p)
+	}
+
+	for _, pattern := range resolved.Protect {
+		if strings.ContainsRune(pattern, '/') {
+			pattern = relative(pattern)
+		}
+		state.protect = append(state.protect, pattern)
+	}
+	state.protectKeys = append(state.protectKeys, resolved.ProtectKeys...)
+	if resolved.Mask && !flagGiven(argMask) {
+		state.mask = true
+	}
+	if len(resolved.MaskKeys) > 0 && !flagGiven(argMaskKeys) {
+		state.maskKeys = resolved.MaskKeys
+	}
+	if !flagGiven(argEnvFile) && !flagGiven(argCascade) {
+		switch {
+		case len(resolved.Files) == 1:
+			state.Path = relative(resolved.Files[0])
+		case len(resolved.Files) > 1:
+			state.cascade = true
+			for _, f := range resolved.Files {
+				state.layers = append(state.layers, relative(f))
+			}
+		case resolved.Cascade:
+			state.cascade = true
+			state.Path = filepath.Join(root, envFileDefault)
+		}
+	}
+	if len(resolved.Stage) > 0 && !flagGiven(argStage) {
+		state.stage = resolved.Stage
+	}
+	if len(resolved.Format) > 0 {
+		f, ok := format.For(resolved.Format)
+		if !ok {
+			return errors.New("format " + resolved.Format + " of " + path + " is not registered")
+		}
+		state.defaultFormat = f
+	}
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("Using %s profile %q\n", path, state.profile)
+	}
+	return nil
+}
+
+// ShowConfig prints the effective configuration after the user config, projectFile and -profile are applied
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+func ShowConfig(figs figtree.Plant, state *stateful) {
+	if state.config != configShow {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s only supports %s\n", argConfig, configShow)
+		os.Exit(1)
+	}
+	shown := effectiveConfig{
+		ProjectConfig: state.project,
+		Profile:       state.profile,
+		File:          state.Path,
+		Layers:        state.layers,
+		Cascade:       state.cascade,
+		Stage:         state.stage,
+		Protect:       state.protect,
+		ProtectKeys:   state.protectKeys,
+		Mask:          state.mask,
+		MaskKeys:      state.maskKeys,
+		Formats:       []string{},
+	}
+	if path, ok := userConfigFile(); ok {
+		shown.UserConfig = path
+	}
+	for _, f := range state.formats {
+		shown.Formats = append(shown.Formats, f.Name())
+	}
+	if state.defaultFormat != nil {
+		shown.DefaultFormat = state.defaultFormat.Name()
+	}
+	output, err := yaml.Marshal(shown)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argConfig, err)
+		os.Exit(1)
+	}
+	fmt.Print(string(output))
+	os.Exit(0)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andreimerlescu/goenv/env"
+	"golang.org/x/term"
+)
+
+// protectedPath reports whether path matches one of the -protect globs of the policy
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		path: the env file that would be written
+func protectedPath(state *stateful, path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return matchAny(state.protect, abs)
+}
+
+// protectedKeys returns the keys that match one of the -protect-keys globs of the policy, compared case-insensitively
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		keys: the keys that would change
+func protectedKeys(state *stateful, keys []string) []string {
+	matched := make([]string, 0)
+	for _, key := range keys {
+		for _, pattern := range state.protectKeys {
+			pattern = strings.TrimSpace(pattern)
+			if ok, _ := filepath.Match(strings.ToUpper(pattern), strings.ToUpper(key)); ok && len(pattern) > 0 {
+				matched = append(matched, key)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// changedKeys returns the sorted keys that were added, removed or changed between from and to
+func changedKeys(from, to map[string]string) []string {
+	report := diffEnvs(from, to, nil)
+	keys := make([]string, 0, len(report.Added)+len(report.Removed)+len(report.Changed))
+	for _, entry := range append(append(report.Added, report.Removed...), report.Changed...) {
+		keys = append(keys, entry.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// guardWrite exits before a write to path unless the policy allows it. A write is protected when path matches
+// -protect, when it is the -prod -file or when it changes a key matching -protect-keys. GOENV_NEVER_WRITE_PRODUCTION
+// set to true refuses every protected write and set to false disables the policy. Otherwise a protected write needs
+// the file name typed at an interactive prompt or -force, which is recorded in the audit log.
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		path: the env file that would be written
+// 		keys: the keys the write would change
+func guardWrite(state *stateful, path string, keys []string) {
+	sensitive := protectedKeys(state, keys)
+	protected := protectedPath(state, path) || (path == state.Path && state.prodProtected) || len(sensitive) > 0
+	// -dry-run previews the write without making it, so there is nothing to confirm
+	if !protected || state.confirmed[path] || state.dryRun {
+		return
+	}
+	if env.Exists(EnvNeverWriteProduction) {
+		if !env.Bool(EnvNeverWriteProduction, true) {
+			return
+		}
+		_, _ = fmt.Fprintln(os.Stderr, "HALT: PRODUCTION IS PROTECTED! WRITE OPERATION CANCELED.")
+		os.Exit(1)
+	}
+	reason := path + " is protected"
+	if len(sensitive) > 0 {
+		reason = fmt.Sprintf("%s changes protected keys %s", path, strings.Join(sensitive, ", "))
+	}
+	if state.force {
+		if err := recordForce(state, path); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "HALT: -%s could not be recorded: %v\n", argForce, err)
+			os.Exit(1)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: %s, writing anyway because of -%s\n", reason, argForce)
+		state.confirmed[path] = true
+		return
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		_, _ = fmt.Fprintf(os.Stderr, "HALT: %s! WRITE OPERATION CANCELED. Use -%s to write without confirmation.\n", reason, argForce)
+		os.Exit(1)
+	}
+	name := filepath.Base(path)
+	_, _ = fmt.Fprintf(os.Stderr, "%s. Type %s to confirm the write: ", reason, name)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != name {
+		_, _ = fmt.Fprintln(os.Stderr, "HALT: CONFIRMATION DID NOT MATCH! WRITE OPERATION CANCELED.")
+		os.Exit(1)
+	}
+	state.confirmed[path] = true
+}
+
+// recordForce appends a force entry to the audit log before a protected write that skipped confirmation
+//
+// Parameters:
+// 	 	state: Read-Only verification on export options being singular in choice
+// 		path: the protected env file being written
+func recordForce(state *stateful, path string) error {
+	if state.dryRun {
+		return nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	return appendAudit(state.auditLog, []auditEntry{{
+		Time:      time.Now().UTC(),
+		User:      env.User().Username,
+		Host:      host,
+		File:      abs,
+		Operation: argForce,
+		Forced:    true,
+	}})
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/format"
+)
+
+// fileResult is the outcome of running goenv against a single file discovered by -recursive
+type fileResult struct {
+	File     string `json:"file" yaml:"file" toml:"file" xml:"file" ini:"file"`
+	ExitCode int    `json:"exit_code" yaml:"exit_code" toml:"exit_code" xml:"exit_code" ini:"exit_code"`
+	Stdout   string `json:"stdout" yaml:"stdout" toml:"stdout" xml:"stdout" ini:"stdout"`
+	Stderr   string `json:"stderr" yaml:"stderr" toml:"stderr" xml:"stderr" ini:"stderr"`
+}
+
+// recursiveIncludes are the default -include patterns of -recursive
+var recursiveIncludes = []string{envFileDefault, envFileDefault + ".*", "*" + envFileDefault}
+
+// recursiveExcludes returns the default -exclude patterns which skip vendored directories and registered exports
+func recursiveExcludes() []string {
+	excludes := []string{".git", "node_modules", "vendor"}
+	for _, f := range format.All() {
+		excludes = append(excludes, "*"+f.Extension())
+	}
+	return excludes
+}
+
+// matchAny reports whether the base name of rel, or rel itself for patterns containing a separator, matches a pattern
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if len(pattern) == 0 {
+			continue
+		}
+		target := filepath.Base(rel)
+		if strings.ContainsRune(pattern, '/') {
+			target = filepath.ToSlash(rel)
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverFiles walks root for files matching includes and not matching excludes, skipping excluded directories
+//
+// Parameters:
+// 		root: directory to walk
+// 		includes: globs a file must match
+// 		excludes: globs that exclude a file or an entire directory
+func discoverFiles(root string, includes, excludes []string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return relErr
+		}
+		if d.IsDir() {
+			if rel != "." && matchAny(excludes, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchAny(includes, rel) && !matchAny(excludes, rel) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// stripArgs removes each flag in valued (along with its value) and each flag in bools from args
+func stripArgs(args []string, valued, bools []string) []string {
+	has := func(names []string, arg string) (bool, bool) {
+		name := strings.TrimLeft(arg, "-")
+		name, _, inline := strings.Cut(name, "=")
+		for _, n := range names {
+			if strings.EqualFold(n, name) {
+				return true, inline
+			}
+		}
+		return false, inline
+	}
+	kept := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			kept = append(kept, args[i])
+			continue
+		}
+		if ok, inline := has(valued, args[i]); ok {
+			if !inline {
+				i++
+			}
+			continue
+		}
+		if ok, _ := has(bools, args[i]); ok {
+			continue
+		}
+		kept = append(kept, args[i])
+	}
+	return kept
+}
+
+// Recursive runs goenv with the same arguments against every env file discovered under -recursive using -jobs
+// workers and prints one report with the exit code of each file, exiting 1 when any file failed
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -recursive services -has -env DATABASE_URL
+// 		goenv -recursive services -include '.env.production' -check-example -report json
+func Recursive(figs figtree.Plant, state *stateful) {
+	if state.report != reportText && state.report != reportJson {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s must be %s or %s\n", argReport, reportText, reportJson)
+		os.Exit(1)
+	}
+	includes, excludes := state.include, state.exclude
+	if len(includes) == 0 {
+		includes = recursiveIncludes
+	}
+	if len(excludes) == 0 {
+		excludes = recursiveExcludes()
+	}
+	files, err := discoverFiles(state.recursive, includes, excludes)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argRecursive, err)
+		os.Exit(1)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argRecursive, err)
+		os.Exit(1)
+	}
+	args := stripArgs(os.Args[1:], []string{argRecursive, argInclude, argExclude, argJobs, argReport, argEnvFile}, []string{})
+
+	results := make([]fileResult, len(files))
+	jobs := max(state.jobs, 1)
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, file string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var stdout, stderr bytes.Buffer
+			cmd := exec.Command(self, append([]string{"-" + argEnvFile, file}, args...)...)
+			cmd.Stdout, cmd.Stderr = &stdout, &stderr
+			code := 0
+			if runErr := cmd.Run(); runErr != nil {
+				var exitErr *exec.ExitError
+				if errors.As(runErr, &exitErr) {
+					code = exitErr.ExitCode()
+				} else {
+					code = 1
+					stderr.WriteString(runErr.Error())
+				}
+			}
+			results[i] = fileResult{File: file, ExitCode: code, Stdout: stdout.String(), Stderr: stderr.String()}
+		}(i, file)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.ExitCode != 0 {
+			failed++
+		}
+	}
+	if state.report == reportJson {
+		output, jsonErr := json.MarshalIndent(results, "", "  ")
+		if jsonErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argRecursive, jsonErr)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+	} else {
+		for _, r := range results {
+			fmt.Printf("==> %s (exit %d) <==\n", r.File, r.ExitCode)
+			if out := strings.TrimRight(r.Stdout+r.Stderr, "\n"); len(out) > 0 {
+				fmt.Println(out)
+			}
+		}
+		fmt.Printf("%d files, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/env"
+)
+
+// lineKey returns the key assigned by line using the same rules as Run
+func lineKey(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < 3 {
+		return "", false
+	}
+	parts := strings.SplitN(trimmed, env.MapItemSeparator, env.MapSplitN)
+	if len(parts) != 2 {
+		return "", false
+	}
+	return strings.TrimSpace(parts[0]), true
+}
+
+// renameKey rewrites the line assigning from so that it assigns to instead, keeping its position and value as-is
+//
+// Parameters:
+// 		contents: the env file
+// 		from: the key to rename, compared case-insensitively like -has
+// 		to: the new key which must not already be assigned
+func renameKey(contents []byte, from, to string) ([]byte, error) {
+	lines := strings.Split(string(contents), "\n")
+	found := -1
+	for i, line := range lines {
+		key, ok := lineKey(line)
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(key, to) && !strings.EqualFold(key, from) {
+			return nil, fmt.Errorf("%s is already defined on line %d", key, i+1)
+		}
+		if strings.EqualFold(key, from) {
+			found = i
+			_, value, _ := strings.Cut(line, env.MapItemSeparator)
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			lines[i] = indent + to + env.MapItemSeparator + value
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("%s is not defined", from)
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// renameOwners returns the files among sources that assign key, in the order they are read, so that a key defined in
+// an include is renamed where it is defined rather than in argEnvFile
+func renameOwners(sources []sourceLine, key string) []string {
+	owners := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, line := range sources {
+		k, ok := lineKey(line.Text)
+		if !ok || !strings.EqualFold(k, key) || seen[line.File] {
+			continue
+		}
+		seen[line.File] = true
+		owners = append(owners, line.File)
+	}
+	return owners
+}
+
+// Rename renames -env to -to in place within argEnvFile and every file it includes that defines -env
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -mv -env DB_PASS -to DATABASE_PASSWORD -write
+func Rename(figs figtree.Plant, state *stateful) {
+	from, to := strings.TrimSpace(state.env), strings.TrimSpace(state.to)
+	if len(from) == 0 || len(to) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s requires -%s and -%s\n", argMv, argEnv, argTo)
+		os.Exit(1)
+	}
+	owners := renameOwners(state.sources, from)
+	if len(owners) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %s is not defined in %s or the files it includes\n", argMv, from, state.Path)
+		os.Exit(1)
+	}
+	for _, line := range state.sources {
+		if key, ok := lineKey(line.Text); ok && strings.EqualFold(key, to) && !strings.EqualFold(key, from) {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %s is already defined on line %d of %s\n", argMv, key, line.Line, line.File)
+			os.Exit(1)
+		}
+	}
+	originals := make(map[string][]byte, len(owners))
+	renamed := make(map[string][]byte, len(owners))
+	for _, owner := range owners {
+		contents, err := os.ReadFile(owner)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v\n", owner, err)
+			os.Exit(1)
+		}
+		updated, err := renameKey(contents, from, to)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v in %s\n", argMv, err, owner)
+			os.Exit(1)
+		}
+		originals[owner], renamed[owner] = contents, updated
+	}
+	if !state.write {
+		for _, owner := range owners {
+			if len(owners) > 1 {
+				fmt.Printf("==> %s <==\n", owner)
+			}
+			fmt.Print(string(renamed[owner]))
+		}
+		fmt.Printf("The -write flag can be used to rename %s to %s in %s\n", from, to, strings.Join(owners, ", "))
+		os.Exit(0)
+	}
+	for _, owner := range owners {
+		guardWrite(state, owner, []string{from, to})
+		gitGuard(state, owner, pickValues(parseEnvs(renamed[owner]), []string{to}))
+	}
+	for _, owner := range owners {
+		if writeErr := saveFileAtomic(state, owner, renamed[owner]); writeErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s", owner, writeErr)
+			os.Exit(1)
+		}
+		auditChange(state, argMv, owner, parseEnvs(originals[owner]), parseEnvs(renamed[owner]))
+		if *figs.Bool(argVerbose) {
+			fmt.Printf("Renamed %s to %s in %s\n", from, to, owner)
+		}
+	}
+	finish(state)
+}
+
+// Copy appends -env from the envs of argEnvFile to -to-file, named -to when it is provided
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env.staging -cp -env API_URL -to-file .env.production -prod -write
+// 		goenv -cp -env DB_PASS -to DATABASE_PASSWORD -to-file other.env -write
+func Copy(figs figtree.Plant, envs map[string]string, state *stateful) {
+	from := strings.TrimSpace(state.env)
+	if len(from) == 0 || len(state.toFile) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s requires -%s and -%s\n", argCp, argEnv, argToFile)
+		os.Exit(1)
+	}
+	key, value, found := "", "", false
+	for k, v := range envs {
+		if strings.EqualFold(k, from) {
+			key, value, found = k, v, true
+			break
+		}
+	}
+	if !found {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %s is not defined in %s\n", argCp, from, state.Path)
+		os.Exit(1)
+	}
+	if to := strings.TrimSpace(state.to); len(to) > 0 {
+		key = to
+	}
+	contents, err := os.ReadFile(state.toFile)
+	if err != nil && !os.IsNotExist(err) {
+		_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v\n", state.toFile, err)
+		os.Exit(1)
+	}
+	for i, line := range strings.Split(string(contents), "\n") {
+		if k, ok := lineKey(line); ok && strings.EqualFold(k, key) {
+			_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %s is already defined on line %d of %s\n", argCp, k, i+1, state.toFile)
+			os.Exit(1)
+		}
+	}
+	line := key + env.MapItemSeparator + value + "\n"
+	if !state.write {
+		fmt.Print(line)
+		fmt.Printf("The -write flag can be used to copy %s to %s\n", key, state.toFile)
+		os.Exit(0)
+	}
+	guardWrite(state, state.toFile, []string{key})
+	gitGuard(state, state.toFile, map[string]string{key: value})
+	if len(contents) > 0 && contents[len(contents)-1] != '\n' {
+		contents = append(contents, '\n')
+	}
+	copied := append(contents, line...)
+	if writeErr := saveFileAtomic(state, state.toFile, copied); writeErr != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s", state.toFile, writeErr)
+		os.Exit(1)
+	}
+	auditChange(state, argCp, state.toFile, parseEnvs(contents), parseEnvs(copied))
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("Copied %s to %s as %s\n", from, state.toFile, key)
+	}
+	finish(state)
+}
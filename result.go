@@ -7,7 +7,7 @@ import (
 	"strings"
 
 	"github.com/andreimerlescu/figtree/v2"
-	"github.com/andreimerlescu/goenv/env"
+	"github.com/andreimerlescu/goenv/format"
 )
 
 // Result takes the modified envs and either renders their output formats or saves them to disk
@@ -27,48 +27,100 @@ func Result(
 		state.Envs = append(state.Envs, fmt.Sprintf("%s=%s", e, v))
 	}
 
-	if state.toJson || state.mkAll {
-		processJson(figs, envs, state)
+	if state.fixPerms {
+		FixPerms(figs, state)
 	}
 
-	if state.toIni || state.mkAll {
-		processIni(figs, envs, state)
+	if state.checkPerms {
+		CheckPerms(figs, state)
 	}
 
-	if state.toYaml || state.mkAll {
-		processYaml(figs, envs, state)
+	if state.scan {
+		Scan(figs, state)
 	}
 
-	if state.toToml || state.mkAll {
-		processToml(figs, envs, state)
+	if state.undo || state.redo {
+		Undo(figs, envs, state)
 	}
 
-	if state.toXml || state.mkAll {
-		processXml(figs, envs, state)
+	if state.mv {
+		Rename(figs, state)
 	}
 
-	var out bytes.Buffer
-	for e, v := range envs {
-		e, v = strings.TrimSpace(e), strings.TrimSpace(v)
-		out.WriteString(fmt.Sprintf("%s=%s\n", e, v))
+	if state.cp {
+		Copy(figs, envs, state)
 	}
-	if state.write {
-		if env.Bool(EnvNeverWriteProduction, state.prodProtected) {
-			_, _ = fmt.Fprintln(os.Stderr, "HALT: PRODUCTION IS PROTECTED! WRITE OPERATION CANCELED.")
-			os.Exit(1)
+
+	if state.checkExample {
+		CheckExample(figs, envs, state)
+	}
+
+	if state.syncExample {
+		SyncExample(figs, envs, state)
+	}
+
+	exports := state.formats
+	if state.mkAll {
+		exports = format.All()
+	}
+	// encrypted values stay encrypted in -file and are only decrypted for queries and exports
+	plain := envs
+	if state.drift || len(state.diff) > 0 || len(state.template) > 0 || len(exports) > 0 || state.printer ||
+		(state.defaultFormat != nil && !state.write && !state.has && !state.is) {
+		plain = decryptEnvs(envs, state)
+	}
+
+	if state.drift {
+		Drift(figs, plain, state)
+	}
+
+	if len(state.diff) > 0 {
+		Diff(figs, plain, state)
+	}
+
+	if len(state.template) > 0 {
+		processTemplate(figs, plain, state)
+	}
+
+	shown := state.masker.Envs(plain)
+	for _, f := range exports {
+		processFormat(figs, shown, state, f)
+	}
+
+	var printed bytes.Buffer
+	for e := range envs {
+		e = strings.TrimSpace(e)
+		masked := state.masker.Value(e, strings.TrimSpace(plain[e]))
+		if state.quote {
+			masked = quoteValue(masked)
 		}
-		if writeErr := os.WriteFile(state.Path, out.Bytes(), 0644); writeErr != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s", state.Path, writeErr)
+		printed.WriteString(fmt.Sprintf("%s=%s\n", e, masked))
+	}
+	if state.sources == nil {
+		// -merge and -capture replace -file before it was read
+		state.sources, _ = readSources(sourceFiles(state))
+	}
+	before := parseEnvs(joinSources(state.sources))
+	if state.created {
+		// -file was created with -env on it, so the write started from nothing
+		before = map[string]string{}
+	}
+	if state.write {
+		// lines are edited in place so that -dry-run shows exactly the lines a write changes
+		if writeErr := writeOwners(envs, state); writeErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s\n", state.Path, writeErr)
 			os.Exit(1)
 		}
+		auditChange(state, operationOf(state), state.Path, before, envs)
+		finish(state)
+	} else if state.printer {
+		fmt.Println(printed.String())
 		os.Exit(0)
-	} else if *figs.Bool(argPrint) {
-		fmt.Println(out.String())
-		os.Exit(0)
+	} else if state.defaultFormat != nil && !state.has && !state.is {
+		processFormat(figs, shown, state, state.defaultFormat)
 	}
 	if *figs.Bool(argVerbose) {
 		fmt.Println("Finished executing!")
-		os.Exit(0)
 	}
-	os.Exit(0)
+	finish(state)
 }
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/andreimerlescu/figtree/v2"
 	"github.com/andreimerlescu/goenv/env"
+	"github.com/andreimerlescu/goenv/format"
 )
 
 // Run is the primary goenv application
@@ -30,6 +32,87 @@ func Run(figs figtree.Plant) {
 		env:   *figs.String(argEnv),
 		value: *figs.String(argValue),
 
+		out:     *figs.String(argOut),
+		outDir:  *figs.String(argOutDir),
+		outName: *figs.String(argOutName),
+
+		template: *figs.String(argTemplate),
+		envsubst: *figs.Bool(argEnvSubst),
+		strict:   *figs.Bool(argStrict),
+
+		cascade: *figs.Bool(argCascade),
+		stage:   *figs.String(argStage),
+		explain: *figs.Bool(argExplain),
+
+		diff:    *figs.String(argDiff),
+		unified: *figs.Bool(argUnified),
+		mask:    *figs.Bool(argMask),
+
+		maskKeys:  *figs.List(argMaskKeys),
+		maskStyle: *figs.String(argMaskStyle),
+		reveal:    *figs.List(argReveal),
+
+		encrypt:    *figs.Bool(argEncrypt),
+		decrypt:    *figs.Bool(argDecrypt),
+		rekey:      *figs.Bool(argRekey),
+		keyFile:    *figs.String(argKeyFile),
+		newKeyFile: *figs.String(argNewKeyFile),
+		gitignore:  *figs.Bool(argGitIgnore),
+
+		perms:      *figs.String(argPerms),
+		checkPerms: *figs.Bool(argCheckPerms),
+		fixPerms:   *figs.Bool(argFixPerms),
+
+		generate: *figs.Bool(argGenerate),
+		rotate:   *figs.Bool(argRotate),
+		kind:     *figs.String(argKind),
+		length:   *figs.Int(argLength),
+
+		scan:      *figs.Bool(argScan),
+		scanAllow: *figs.String(argScanAllow),
+		entropy:   *figs.Float64(argEntropy),
+
+		undo:       *figs.Bool(argUndo),
+		redo:       *figs.Bool(argRedo),
+		history:    *figs.Bool(argHistory),
+		journalDir: *figs.String(argJournalDir),
+
+		dryRun: *figs.Bool(argDryRun),
+
+		checkExample: *figs.Bool(argCheckExample),
+		syncExample:  *figs.Bool(argSyncExample),
+		example:      *figs.String(argExample),
+		placeholder:  *figs.String(argPlaceholder),
+
+		merge:    *figs.List(argMerge),
+		strategy: *figs.String(argStrategy),
+
+		matrix:       *figs.List(argMatrix),
+		matrixFormat: *figs.String(argMatrixFormat),
+		cell:         *figs.String(argCell),
+
+		mv:     *figs.Bool(argMv),
+		cp:     *figs.Bool(argCp),
+		to:     *figs.String(argTo),
+		toFile: *figs.String(argToFile),
+
+		recursive: *figs.String(argRecursive),
+		include:   *figs.List(argInclude),
+		exclude:   *figs.List(argExclude),
+		jobs:      *figs.Int(argJobs),
+		report:    *figs.String(argReport),
+
+		drift:   *figs.Bool(argDrift),
+		pid:     *figs.Int(argPid),
+		environ: *figs.String(argEnviron),
+
+		capture: *figs.Bool(argCapture),
+		prefix:  *figs.String(argPrefix),
+		quote:   *figs.Bool(argQuote),
+
+		profile: *figs.String(argProfile),
+		config:  *figs.String(argConfig),
+
 		mkAll:   *figs.Bool(argMkAll),
 		init:    *figs.Bool(argInit),
 		printer: *figs.Bool(argPrint),
@@ -42,15 +125,54 @@ func Run(figs figtree.Plant) {
 		has: *figs.Bool(argHas),
 		not: *figs.Bool(argNot),
 
-		prod:          *figs.Bool(argProd),
-		isProd:        strings.Contains(*figs.String(argEnvFile), "prod"),
-		prodProtected: strings.Contains(*figs.String(argEnvFile), "prod") == true,
+		prod:        *figs.Bool(argProd),
+		protect:     *figs.List(argProtect),
+		protectKeys: *figs.List(argProtectKeys),
+		force:       *figs.Bool(argForce),
+		confirmed:   map[string]bool{},
+
+		audit:    *figs.Bool(argAudit),
+		auditLog: *figs.String(argAuditLog),
+		since:    *figs.String(argSince),
+		until:    *figs.String(argUntil),
+
+		formats: selectedFormatters(figs),
+	}
+
+	if state.dryRun {
+		// -dry-run runs the same pipeline as -write and stops at saveFile
+		state.write = true
+	}
+
+	if err := applyProject(figs, state); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", projectFile, err)
+		os.Exit(1)
+	}
+	masker, err := newMasker(state)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	state.masker = masker
+
+	if len(state.config) > 0 {
+		ShowConfig(figs, state)
+	}
+
+	if len(state.recursive) > 0 {
+		Recursive(figs, state)
+	}
+
+	if len(state.matrix) > 0 {
+		Matrix(figs, state)
+	}
+
+	if state.audit {
+		Audit(figs, state)
+	}
 
-		toIni:  *figs.Bool(argIni),
-		toYaml: *figs.Bool(argYaml),
-		toXml:  *figs.Bool(argXml),
-		toJson: *figs.Bool(argJson),
-		toToml: *figs.Bool(argToml),
+	if state.history {
+		History(figs, state)
 	}
 
 	if len(state.Path) == 0 && (state.write || state.init) {
@@ -63,8 +185,26 @@ func Run(figs figtree.Plant) {
 			state.isProd = false
 		}
 	}
+	if state.cascade {
+		dir := filepath.Dir(state.Path)
+		if len(state.layers) > 0 {
+			// layers listed by a profile of the projectFile
+			dir = filepath.Dir(state.layers[0])
+			state.layers = existingFiles(state.layers)
+		} else {
+			state.layers = existingFiles(cascadeFiles(dir, state.stage))
+		}
+		state.Path = filepath.Join(dir, envFileDefault)
+		if len(state.layers) == 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "no env files found in %s for -%s %s\n", dir, argStage, state.stage)
+			os.Exit(1)
+		}
+		if *figs.Bool(argVerbose) {
+			fmt.Printf("Using -%s layers %s\n", argCascade, strings.Join(state.layers, " < "))
+		}
+	}
 	d, err := os.Stat(state.Path)
-	if os.IsNotExist(err) {
+	if os.IsNotExist(err) && !state.cascade && len(state.merge) == 0 && !state.capture {
 		if !state.init && !state.write {
 			_, _ = fmt.Fprintf(os.Stderr, "%s does not exists, use -write to create\n", state.Path)
 			os.Exit(1)
@@ -78,19 +218,30 @@ func Run(figs figtree.Plant) {
 		state.Info.Mode = d.Mode()
 	}
 
-	state.isProd = strings.Contains(state.Path, ".env.production") || state.prod
+	state.isProd = protectedPath(state, state.Path) || state.prod || (state.cascade && state.stage == "production")
 	if state.isProd {
 		fmt.Println("Using PRODUCTION environment file")
 	} else if *figs.Bool(argVerbose) {
 		fmt.Printf("Using %s environment file", state.Path)
 	}
-	state.prodProtected = env.Bool(EnvNeverWriteProduction, state.isProd)
+	state.prodProtected = state.isProd
 
 	if *figs.Bool(argCleanAll) {
-		for _, ext := range []string{outFormatJson, outFormatYaml, outFormatToml, outFormatXml, outFormatIni} {
-			ext := ext
+		state.mkAll = true // -cleanall targets the same locations as -mkall
+		paths := make([]string, 0, len(format.All())+1)
+		for _, f := range format.All() {
+			path, pathErr := outputPath(state, f.Extension())
+			if pathErr != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error rendering -%s %q: %v\n", argOutName, state.outName, pathErr)
+				os.Exit(1)
+			}
+			paths = append(paths, path)
+		}
+		if len(state.out) > 0 {
+			paths = append(paths, state.out)
+		}
+		for _, path := range paths {
 			err = nil
-			path := state.Path + ext
 			_, err = os.Stat(path)
 			if os.IsNotExist(err) {
 				continue
@@ -102,42 +253,87 @@ func Run(figs figtree.Plant) {
 			if err != nil {
 				_, _ = fmt.Fprintf(os.Stderr, "path %s err = %v\n", path, err)
 			} else {
-				if !env.Bool(AmGoEnvNeverDelete, !(state.write || state.rm)) {
+				if state.dryRun {
+					previewFile(state, path, nil)
+				} else if !env.Bool(AmGoEnvNeverDelete, !(state.write || state.rm)) {
 					err = os.Remove(path)
 					if err != nil {
 						_, _ = fmt.Fprintf(os.Stderr, "%s is not writable: %v\n", path, err)
 						os.Exit(1)
 					}
+					auditChange(state, argCleanAll, path, nil, nil)
 				}
 				if !(state.write || state.rm) {
 					fmt.Printf("The -write flag can be used to remove %s\n", path)
 				}
 			}
 		}
-		os.Exit(0)
+		finish(state)
 	}
 
 	Sanity(figs, state)
+	if state.explain {
+		Explain(figs, state)
+	}
+	if state.gitignore {
+		GitIgnore(figs, state)
+	}
+	if state.encrypt && !state.generate {
+		Encrypt(figs, state)
+	}
+	if state.decrypt {
+		Decrypt(figs, state)
+	}
+	if state.rekey {
+		Rekey(figs, state)
+	}
+	if len(state.merge) > 0 {
+		Merge(figs, state)
+	}
+	if state.capture {
+		Capture(figs, state)
+	}
 	triedWrite := false
 retry:
 	_, err = os.Lstat(state.Path)
+	if state.cascade {
+		err = nil
+	}
 	if os.IsNotExist(err) {
 		if state.init && !triedWrite {
-			if writeErr := os.WriteFile(state.Path, []byte{}, 0644); writeErr != nil {
+			guardWrite(state, state.Path, nil)
+			if state.dryRun {
+				// an empty file has no lines to diff
+				fmt.Printf("--- %s\n+++ %s\n", os.DevNull, state.Path)
+				os.Exit(dryRunChanged)
+			}
+			if writeErr := os.WriteFile(state.Path, []byte{}, 0600); writeErr != nil {
 				_, _ = fmt.Fprintf(os.Stderr, "-init failed with: %v", writeErr)
 				os.Exit(1)
 			}
+			auditChange(state, argInit, state.Path, nil, nil)
 			os.Exit(0)
 		} else if state.write && !triedWrite {
+			guardWrite(state, state.Path, []string{state.env})
+			gitGuard(state, state.Path, map[string]string{state.env: state.value})
 			var bb bytes.Buffer
+			value := state.value
+			if state.quote {
+				value = quoteValue(value)
+			}
 			bb.WriteString(state.env)
 			bb.WriteString("=")
-			bb.WriteString(state.value)
+			bb.WriteString(value)
 			bb.WriteString("\n")
-			if writeErr := os.WriteFile(state.Path, bb.Bytes(), 0644); writeErr != nil {
+			if state.dryRun {
+				// nothing is created, so -env is added to the empty file instead
+				state.sources = []sourceLine{}
+				state.add = state.add || len(strings.TrimSpace(state.env)) > 0
+			} else if writeErr := os.WriteFile(state.Path, bb.Bytes(), 0600); writeErr != nil {
 				_, _ = fmt.Fprintf(os.Stderr, "error writing %d bytes to %s due to %v", bb.Len(), state.Path, errors.Join(err, writeErr))
 				os.Exit(1)
 			}
+			state.created = !state.dryRun
 			triedWrite = true
 			goto retry
 		}
@@ -152,18 +348,25 @@ retry:
 		os.Exit(1)
 	}
 
-	var contents []byte
-	contents, err = os.ReadFile(state.Path)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v", state.Path, err)
-		os.Exit(1)
+	if state.sources == nil {
+		state.sources, err = readSources(sourceFiles(state))
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v", state.Path, err)
+			os.Exit(1)
+		}
+	}
+	contents := joinSources(state.sources)
+	if !state.checkPerms && !state.fixPerms {
+		enforcePerms(state)
 	}
 
-	if size := len(contents); size == 0 && !(state.init || state.write || state.add) {
+	if size := len(contents); size == 0 && !(state.init || state.write || state.add || state.undo || state.redo) {
 		_, _ = fmt.Fprintf(os.Stderr, "Error: %s %d bytes", state.Path, size)
 		os.Exit(1)
 	}
 
+	// with -cascade or includes a key can be defined more than once, so -has and -is wait for the effective value
+	merged := state.cascade || includesOthers(state.sources, state.Path)
 	envs := make(map[string]string)
 	for _, line := range strings.Split(string(contents), "\n") {
 		line = strings.TrimSpace(line)
@@ -179,7 +382,7 @@ retry:
 		if state.rm && isThis {
 			continue
 		}
-		if state.has && isThis {
+		if state.has && isThis && !merged {
 			code := 0
 			if state.not {
 				code = 1
@@ -194,11 +397,19 @@ retry:
 			os.Exit(code)
 		}
 
-		isThat := strings.EqualFold(strings.TrimSpace(parts[1]), strings.TrimSpace(state.value))
+		value := unquoteValue(strings.TrimSpace(parts[1]))
+		if (state.is && isThis) || (state.rm && len(strings.TrimSpace(state.value)) > 0) {
+			// values are only decrypted when -value is compared with them, otherwise ciphertext is left as-is
+			if value, err = decryptValue(state, value); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error decrypting %s: %v\n", strings.TrimSpace(parts[0]), err)
+				os.Exit(1)
+			}
+		}
+		isThat := strings.EqualFold(value, strings.TrimSpace(state.value))
 		if state.rm && isThat {
 			continue
 		}
-		if state.is && isThat {
+		if state.is && isThat && !merged {
 			code := 0
 			if *figs.Bool(argNot) {
 				code = 1
@@ -213,7 +424,13 @@ retry:
 			os.Exit(code)
 		}
 
-		envs[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		envs[strings.TrimSpace(parts[0])] = unquoteValue(strings.TrimSpace(parts[1]))
+	}
+	if merged && (state.has || state.is) {
+		queryMerged(state, envs)
+	}
+	if state.generate {
+		Generate(figs, envs, state)
 	}
 	wrote := false
 	if state.add {
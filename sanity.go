@@ -3,8 +3,10 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/format"
 )
 
 // Sanity ensures that you're not exporting to multiple formats at once and uses argVerbose to print statements to STDOUT
@@ -23,79 +25,95 @@ func Sanity(figs figtree.Plant, state *stateful) {
 		panic("Sanity called with nil figtree or state!")
 	}
 	// #begin
-	using := ""
-	selectedOut := false
-
-	// -json
-	if state.toJson && *figs.Bool(argVerbose) {
-		fmt.Println("Using JSON environment file")
-		if state.write && state.isProd && state.prodProtected {
-			fmt.Printf("We'll write to %s%s for you!", state.Path, outFormatJson)
-		}
+	if len(state.out) > 0 && state.mkAll {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT COMBINE -%s with -%s, use -%s and -%s instead\n", argOut, argMkAll, argOutDir, argOutName)
+		os.Exit(1)
 	}
-	if state.toJson { // skip selectedOut here
-		using = "json"
-		selectedOut = true
+	if len(state.template) > 0 && (state.mkAll || len(state.formats) > 0) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT COMBINE -%s with -%s or an output format\n", argTemplate, argMkAll)
+		os.Exit(1)
 	}
-
-	// -ini
-	if state.toIni && *figs.Bool(argVerbose) {
-		fmt.Println("Using INI environment file")
-		if state.write && state.isProd && state.prodProtected {
-			fmt.Printf("We'll write to %s%s for you!", state.Path, outFormatIni)
-		}
+	if state.cascade && state.write && !state.mkAll && len(state.formats) == 0 && len(state.template) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT -%s the merged -%s view, use -%s on a single layer instead\n", argWrite, argCascade, argEnvFile)
+		os.Exit(1)
 	}
-	if state.toIni && selectedOut {
-		_, _ = fmt.Fprintf(os.Stderr, "using %s write to %s.%s. ERROR CANNOT COMBINE -json -ini -toml -yaml \n", using, state.Path, using)
+	if state.explain && (state.mkAll || (len(state.formats) > 0 && state.formats[0].Name() != argJson)) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR -%s only supports -%s output\n", argExplain, argJson)
 		os.Exit(1)
-	} else if state.toIni && !selectedOut {
-		selectedOut = true
-		using = "ini"
 	}
-
-	// -yaml
-	if state.toYaml && *figs.Bool(argVerbose) {
-		fmt.Println("Using YAML environment file")
-		if state.write && state.isProd && state.prodProtected {
-			fmt.Printf("We'll write to %s%s for you!\n", state.Path, outFormatYaml)
-		}
+	if len(state.diff) > 0 && (state.mkAll || len(state.template) > 0 || (len(state.formats) > 0 && state.formats[0].Name() != argJson && state.formats[0].Name() != argYaml)) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR -%s only supports -%s -%s or -%s output\n", argDiff, argUnified, argJson, argYaml)
+		os.Exit(1)
 	}
-	if state.toYaml && selectedOut {
-		_, _ = fmt.Fprintf(os.Stderr, "using %s write to %s.%s. ERROR CANNOT COMBINE -json -ini -toml -yaml \n", using, state.Path, using)
+	if state.drift && (state.mkAll || (len(state.formats) > 0 && state.formats[0].Name() != argJson && state.formats[0].Name() != argYaml)) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR -%s only supports -%s or -%s output\n", argDrift, argJson, argYaml)
 		os.Exit(1)
-	} else if state.toYaml && !selectedOut {
-		selectedOut = true
-		using = "yaml"
 	}
-
-	// -xml
-	if state.toXml && *figs.Bool(argVerbose) {
-		fmt.Println("Using XML environment file")
-		if state.write && state.isProd && state.prodProtected {
-			fmt.Printf("We'll write to %s%s for you!\n", state.Path, outFormatXml)
-		}
+	if state.syncExample && state.cascade && state.write {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT -%s the merged -%s view, use -%s on a single layer instead\n", argSyncExample, argCascade, argEnvFile)
+		os.Exit(1)
 	}
-	if state.toXml && selectedOut {
-		_, _ = fmt.Fprintf(os.Stderr, "using %s write to %s.%s. ERROR CANNOT COMBINE -json -ini -toml -yaml \n", using, state.Path, using)
+	if (len(state.merge) > 0 || state.capture) && state.cascade {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT COMBINE -%s or -%s with -%s\n", argMerge, argCapture, argCascade)
 		os.Exit(1)
-	} else if state.toXml && !selectedOut {
-		selectedOut = true
-		using = "xml"
 	}
-
-	// -toml
-	if state.toToml && *figs.Bool(argVerbose) {
-		fmt.Println("Using TOML environment file")
-		if state.write && state.isProd && state.prodProtected {
-			fmt.Printf("We'll write to %s%s for you!\n", state.Path, outFormatToml)
+	if state.mv && (state.cascade || state.cp || len(state.merge) > 0) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT COMBINE -%s with -%s -%s or -%s\n", argMv, argCascade, argCp, argMerge)
+		os.Exit(1)
+	}
+	if state.perms != permsWarn && state.perms != permsFail && state.perms != permsOff {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s must be %s, %s or %s\n", argPerms, permsWarn, permsFail, permsOff)
+		os.Exit(1)
+	}
+	if state.generate && (state.cascade || state.mv || state.cp || len(state.merge) > 0 || state.capture || state.add || state.rm) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT COMBINE -%s with -%s -%s -%s -%s -%s -%s or -%s\n",
+			argGenerate, argCascade, argMv, argCp, argMerge, argCapture, argAdd, argRm)
+		os.Exit(1)
+	}
+	if state.generate && state.length <= 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s must be greater than 0\n", argLength)
+		os.Exit(1)
+	}
+	if (state.undo || state.redo) && (state.undo == state.redo || state.cascade || state.add || state.rm || state.mv ||
+		state.cp || len(state.merge) > 0 || state.capture || state.generate || state.encrypt || state.decrypt || state.rekey) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR -%s and -%s cannot be combined with each other, -%s or another change\n", argUndo, argRedo, argCascade)
+		os.Exit(1)
+	}
+	crypts := 0
+	for _, set := range []bool{state.encrypt, state.decrypt, state.rekey} {
+		if set {
+			crypts++
 		}
 	}
-	if state.toToml && selectedOut {
-		_, _ = fmt.Fprintf(os.Stderr, "using %s write to %s.%s. ERROR CANNOT COMBINE -json -ini -toml -yaml \n", using, state.Path, using)
+	if crypts > 1 || (crypts == 1 && !(state.encrypt && state.generate) && (state.cascade || state.mv || state.cp || len(state.merge) > 0 || state.capture)) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT COMBINE -%s -%s or -%s with each other or with -%s -%s -%s -%s or -%s\n",
+			argEncrypt, argDecrypt, argRekey, argCascade, argMv, argCp, argMerge, argCapture)
 		os.Exit(1)
-	} else if state.toToml && !selectedOut {
+	}
+	if len(state.merge) > 0 && state.capture {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR CANNOT COMBINE -%s with -%s\n", argMerge, argCapture)
+		os.Exit(1)
+	}
+	using := ""
+	selectedOut := false
+	names := make([]string, 0, len(format.All()))
+	for _, f := range format.All() {
+		names = append(names, "-"+f.Name())
+	}
+
+	for _, f := range state.formats {
+		if *figs.Bool(argVerbose) {
+			fmt.Printf("Using %s environment file\n", strings.ToUpper(f.Name()))
+			if state.write && state.isProd && state.prodProtected {
+				fmt.Printf("We'll write to %s%s for you!\n", state.Path, f.Extension())
+			}
+		}
+		if selectedOut {
+			_, _ = fmt.Fprintf(os.Stderr, "using %s write to %s.%s. ERROR CANNOT COMBINE %s \n", using, state.Path, using, strings.Join(names, " "))
+			os.Exit(1)
+		}
 		selectedOut = true
-		using = "toml"
+		using = f.Name()
 	}
 
 	// #done
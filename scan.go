@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/andreimerlescu/figtree/v2"
+	"github.com/andreimerlescu/goenv/env"
+)
+
+// scanRule is a known credential format that -scan looks for in every line of argEnvFile
+type scanRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// scanFinding is a value of argEnvFile that looks like a real credential
+type scanFinding struct {
+	File        string  `json:"file"`
+	Line        int     `json:"line"`
+	Key         string  `json:"key,omitempty"`
+	Rule        string  `json:"rule"`
+	Fingerprint string  `json:"fingerprint"`
+	Entropy     float64 `json:"entropy,omitempty"`
+}
+
+// scanRules are the credential formats -scan reports before falling back to the entropy of a value
+var scanRules = []scanRule{
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+	{Name: "aws-access-key", Pattern: regexp.MustCompile(`\b(AKIA|ASIA|ABIA|ACCA|A3T[A-Z0-9])[A-Z2-7]{16}\b`)},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+// scanTokens splits a value into the runs of characters that make up keys and tokens for the entropy check
+var scanTokens = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+
+// shannonEntropy returns the bits of entropy per character of value
+func shannonEntropy(value string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range value {
+		counts[r]++
+	}
+	entropy := 0.0
+	size := float64(len([]rune(value)))
+	for _, count := range counts {
+		p := float64(count) / size
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// isHex reports whether value only holds hexadecimal digits, which carry at most 4 bits per character
+func isHex(value string) bool {
+	return strings.Trim(value, "0123456789abcdefABCDEF") == ""
+}
+
+// scanAllowlist holds the fingerprints and key globs of the -scan-allow file
+type scanAllowlist struct {
+	fingerprints map[string]bool
+	keys         []string
+}
+
+// readScanAllowlist reads path, one fingerprint such as sha256:1a2b3c4d or key glob per line with # comments, where
+// a missing file is only an error when it was asked for with -scan-allow
+func readScanAllowlist(path string, required bool) (scanAllowlist, error) {
+	allow := scanAllowlist{fingerprints: map[string]bool{}, keys: []string{}}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && !required {
+		return allow, nil
+	}
+	if err != nil {
+		return allow, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "sha256:") {
+			allow.fingerprints[line] = true
+			continue
+		}
+		allow.keys = append(allow.keys, line)
+	}
+	return allow, scanner.Err()
+}
+
+// Allowed reports whether finding is listed by its fingerprint or key
+func (a scanAllowlist) Allowed(finding scanFinding) bool {
+	if a.fingerprints[finding.Fingerprint] {
+		return true
+	}
+	return len(finding.Key) > 0 && (&masker{patterns: a.keys}).Sensitive(finding.Key)
+}
+
+// scanLine returns the findings of a single line of an env file, preferring the known credential formats over the
+// entropy of its tokens
+//
+// Parameters:
+// 		line: the line of the env file
+// 		threshold: the bits per character above which a token is reported, three quarters of it for hexadecimal tokens
+func scanLine(line sourceLine, threshold float64) []scanFinding {
+	text := strings.TrimSpace(line.Text)
+	key, value := "", text
+	if parts := strings.SplitN(text, env.MapItemSeparator, env.MapSplitN); len(parts) == 2 && !strings.HasPrefix(text, "#") {
+		key, value = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	}
+	if isEncrypted(value) {
+		return nil
+	}
+	findings := make([]scanFinding, 0)
+	for _, rule := range scanRules {
+		for _, match := range rule.Pattern.FindAllString(value, -1) {
+			findings = append(findings, scanFinding{File: line.File, Line: line.Line, Key: key, Rule: rule.Name, Fingerprint: maskValue(match)})
+		}
+	}
+	if len(findings) > 0 || threshold <= 0 {
+		return findings
+	}
+	for _, token := range scanTokens.FindAllString(value, -1) {
+		limit := threshold
+		if isHex(token) {
+			limit = threshold * 3 / 4
+		}
+		if entropy := shannonEntropy(token); entropy >= limit {
+			findings = append(findings, scanFinding{File: line.File, Line: line.Line, Key: key, Rule: "high-entropy",
+				Fingerprint: maskValue(token), Entropy: math.Round(entropy*100) / 100})
+		}
+	}
+	return findings
+}
+
+// Scan reports every value of argEnvFile and its includes that looks like a real credential, skipping encrypted
+// values and the entries of -scan-allow, and exits 1 when anything was found so it can gate a commit
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 	 	state: Read-Only verification on export options being singular in choice
+//
+// Usage:
+// 		goenv -file .env.example -scan
+// 		goenv -recursive . -include '.env.example' -scan -json
+func Scan(figs figtree.Plant, state *stateful) {
+	allowPath := state.scanAllow
+	required := allowPath != scanAllowFile
+	if !required && !filepath.IsAbs(allowPath) {
+		// the default allowlist lives next to -file
+		allowPath = filepath.Join(filepath.Dir(state.Path), scanAllowFile)
+	}
+	allow, err := readScanAllowlist(allowPath, required)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s failed with: %v\n", argScanAllow, err)
+		os.Exit(1)
+	}
+	findings := make([]scanFinding, 0)
+	allowed := 0
+	for _, line := range state.sources {
+		for _, finding := range scanLine(line, state.entropy) {
+			if allow.Allowed(finding) {
+				allowed++
+				continue
+			}
+			findings = append(findings, finding)
+		}
+	}
+	code := 0
+	if len(findings) > 0 {
+		code = 1
+	}
+	if len(state.formats) > 0 {
+		output, jsonErr := json.MarshalIndent(findings, "", "  ")
+		if jsonErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error marshalling -%s: %v\n", argScan, jsonErr)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		os.Exit(code)
+	}
+	for _, finding := range findings {
+		fmt.Printf("%s:%d %s %s %s\n", finding.File, finding.Line, finding.Key, finding.Rule, finding.Fingerprint)
+	}
+	if len(findings) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Add a fingerprint or key to %s to allow a finding\n", allowPath)
+	}
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("%d findings, %d allowed in %s\n", len(findings), allowed, state.Path)
+	}
+	os.Exit(code)
+}
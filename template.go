@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/andreimerlescu/figtree/v2"
+)
+
+// templateFuncs are the helpers available to -template beyond the text/template builtins
+//
+// Usage:
+// 		{{ default "localhost" .DB_HOST }}
+// 		{{ required "DB_PASS must be set" .DB_PASS }}
+// 		{{ env "DB_PORT" | quote }}
+// 		{{ .API_KEY | b64enc }}
+func templateFuncs(envs map[string]string) template.FuncMap {
+	return template.FuncMap{
+		"env": func(key string) string {
+			return envs[key]
+		},
+		"default": func(fallback, value string) string {
+			if len(value) == 0 {
+				return fallback
+			}
+			return value
+		},
+		"required": func(msg, value string) (string, error) {
+			if len(value) == 0 {
+				return "", errors.New(msg)
+			}
+			return value, nil
+		},
+		"quote":  strconv.Quote,
+		"squote": func(value string) string { return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'" },
+		"b64enc": func(value string) string { return base64.StdEncoding.EncodeToString([]byte(value)) },
+		"b64dec": func(value string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			return string(decoded), err
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+	}
+}
+
+// envsubstRef matches the braced ${VAR} references replaced by -envsubst, leaving $var untouched for files such as
+// nginx configs that use it themselves
+var envsubstRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// renderTemplate renders contents as a text/template or, when envsubst is set, by replacing ${VAR} references to
+// keys of envs, leaving $VAR and references to unknown keys as they are
+//
+// Parameters:
+// 		name: the name of the template used in error messages
+// 		contents: the template source
+// 		envs: map of environment variables as key=value pairs
+// 		envsubst: use ${VAR} substitution instead of text/template
+// 		strict: fail when the template references a key that is not in envs
+func renderTemplate(name string, contents []byte, envs map[string]string, envsubst, strict bool) ([]byte, error) {
+	if envsubst {
+		missing := make([]string, 0)
+		out := envsubstRef.ReplaceAllStringFunc(string(contents), func(ref string) string {
+			key := envsubstRef.FindStringSubmatch(ref)[1]
+			value, ok := envs[key]
+			if !ok {
+				missing = append(missing, key)
+				return ref
+			}
+			return value
+		})
+		if strict && len(missing) > 0 {
+			return nil, fmt.Errorf("%s references missing keys: %s", name, strings.Join(missing, ", "))
+		}
+		return []byte(out), nil
+	}
+	missingKey := "missingkey=zero"
+	if strict {
+		missingKey = "missingkey=error"
+	}
+	tmpl, err := template.New(name).Option(missingKey).Funcs(templateFuncs(envs)).Parse(string(contents))
+	if err != nil {
+		return nil, err
+	}
+	var bb bytes.Buffer
+	if err = tmpl.Execute(&bb, envs); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+// processTemplate renders -template with the envs of argEnvFile to -out or STDOUT
+//
+// Parameters:
+//   	figs: The CLI state of arguments and inputs to the runtime
+// 		envs: map of environment variables as key=value pairs
+// 	 	state: Read-Only verification on export options being singular in choice
+func processTemplate(figs figtree.Plant, envs map[string]string, state *stateful) {
+	contents, err := os.ReadFile(state.template)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "os.ReadFile(%s) returned err: %v\n", state.template, err)
+		os.Exit(1)
+	}
+	rendered, err := renderTemplate(state.template, contents, envs, state.envsubst, state.strict)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error rendering -%s %s: %v\n", argTemplate, state.template, err)
+		os.Exit(1)
+	}
+	path := state.out
+	if len(path) == 0 {
+		for _, ext := range []string{".tmpl", ".tpl", ".template"} {
+			if strings.HasSuffix(state.template, ext) {
+				path = strings.TrimSuffix(state.template, ext)
+				break
+			}
+		}
+	}
+	if !state.write {
+		if len(path) > 0 && *figs.Bool(argVerbose) {
+			_, _ = fmt.Fprintf(os.Stderr, "The -write flag can be used to write %s\n", path)
+		}
+		fmt.Print(string(rendered))
+		os.Exit(0)
+	}
+	if len(path) == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "-%s %s requires -%s when it does not end with .tmpl\n", argTemplate, state.template, argOut)
+		os.Exit(1)
+	}
+	if writeErr := saveFile(state, path, rendered, 0600); writeErr != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error writing file %s: %s", path, writeErr)
+		os.Exit(1)
+	}
+	if *figs.Bool(argVerbose) {
+		fmt.Printf("Writing file %s\n", path)
+	}
+	finish(state)
+}
@@ -24,6 +24,26 @@ export counterName
 
 counter -name $counterName -reset -yes 1> /dev/null || safe_exit "failed to reset counter"
 
+# keep the audit log, journals, key file and user config of the tests out of the home directory
+declare GOENV_TEST_HOME
+declare AM_GO_ENV_AUDIT_LOG
+declare AM_GO_ENV_JOURNAL_DIR
+declare AM_GO_ENV_CONFIG_FILE
+declare GOENV_KEY_FILE
+
+GOENV_TEST_HOME=$(mktemp -d) || safe_exit "failed to mktemp -d"
+AM_GO_ENV_AUDIT_LOG="${GOENV_TEST_HOME}/audit.log"
+AM_GO_ENV_JOURNAL_DIR="${GOENV_TEST_HOME}/journal"
+AM_GO_ENV_CONFIG_FILE="${GOENV_TEST_HOME}/config.yml"
+GOENV_KEY_FILE="${GOENV_TEST_HOME}/key"
+
+export AM_GO_ENV_AUDIT_LOG
+export AM_GO_ENV_JOURNAL_DIR
+export AM_GO_ENV_CONFIG_FILE
+export GOENV_KEY_FILE
+
+trap 'rm -rf "${GOENV_TEST_HOME}"' EXIT
+
 declare input_file
 input_file="${1:-test_cmds.txt}"
 
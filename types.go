@@ -3,6 +3,8 @@ package main
 import (
 	"os"
 	"time"
+
+	"github.com/andreimerlescu/goenv/format"
 )
 
 type (
@@ -19,11 +21,55 @@ type (
 		Info fileInfo `json:"info" yaml:"info" toml:"info" xml:"info" ini:"info"`
 		Envs []string `json:"envs" yaml:"envs" toml:"envs" xml:"envs" ini:"envs"`
 
-		env, value                           string
-		mkAll, init, printer                 bool
-		add, rm, write                       bool
-		is, not, has                         bool
-		prod, isProd, prodProtected          bool
-		toJson, toYaml, toXml, toIni, toToml bool
+		env, value                   string
+		out, outDir, outName         string
+		template                     string
+		envsubst, strict             bool
+		cascade, explain             bool
+		stage, diff                  string
+		unified, mask                bool
+		maskKeys, reveal             []string
+		maskStyle                    string
+		masker                       *masker
+		encrypt, decrypt, rekey      bool
+		keyFile, newKeyFile          string
+		vault                        *vault
+		gitignore                    bool
+		perms                        string
+		checkPerms, fixPerms         bool
+		generate, rotate             bool
+		kind                         string
+		length                       int
+		scan                         bool
+		scanAllow                    string
+		entropy                      float64
+		undo, redo, history          bool
+		journalDir                   string
+		dryRun, pending, created     bool
+		checkExample, syncExample    bool
+		example, placeholder         string
+		merge, matrix                []string
+		strategy, matrixFormat, cell string
+		mv, cp                       bool
+		to, toFile                   string
+		recursive, report            string
+		include, exclude             []string
+		jobs, pid                    int
+		drift, capture, quote        bool
+		environ, prefix              string
+		profile, project, config     string
+		protect, protectKeys         []string
+		force                        bool
+		confirmed                    map[string]bool
+		audit                        bool
+		auditLog, since, until       string
+		layers                       []string
+		sources                      []sourceLine
+		mkAll, init, printer         bool
+		add, rm, write               bool
+		is, not, has                 bool
+		prod, isProd, prodProtected  bool
+		formats                      []format.Formatter
+		defaultFormat                format.Formatter
 	}
 )
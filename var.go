@@ -1 +1,27 @@
 package main
+
+// sensitiveKeys are the default globs of keys whose values -mask redacts
+var sensitiveKeys = []string{
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*TOKEN*",
+	"*SECRET*",
+	"*API_KEY*",
+	"*PRIVATE_KEY*",
+}
+
+// gitIgnoreEntries are the .gitignore lines that -gitignore adds to keep env files out of git
+var gitIgnoreEntries = []string{
+	envFileDefault,
+	envFileDefault + ".*",
+	"!" + envFileExample,
+}
+
+// protectedFiles are the default globs of env files that need confirmation or -force before they are written
+var protectedFiles = []string{
+	envFileProduction,
+	envFileProduction + ".*",
+	".env.prod",
+	".env.live",
+	"*.production.env",
+}